@@ -0,0 +1,83 @@
+package main
+
+import (
+	"complaint-escalator/internal/email"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+)
+
+// maxEmailRequestSize limits the body of /email/send. Uploads are base64 encoded for
+// sending, so their raw size is about three quarters of email.MaxAttachmentsSize.
+const maxEmailRequestSize = email.MaxAttachmentsSize + 1<<20
+
+// AttachmentRequest represents a file attached to an email request. In JSON the content is base64 encoded.
+type AttachmentRequest struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
+}
+
+// decodeEmailRequest reads an email request either as JSON or as a multipart/form-data
+// upload with the fields subject, body, html_body and complaint_id and files in "attachments"
+func decodeEmailRequest(w http.ResponseWriter, r *http.Request) (EmailRequest, error) {
+	var emailReq EmailRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxEmailRequestSize)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if err := json.NewDecoder(r.Body).Decode(&emailReq); err != nil {
+			return emailReq, fmt.Errorf("invalid JSON request body: %w", err)
+		}
+		return emailReq, nil
+	}
+
+	if err := r.ParseMultipartForm(maxEmailRequestSize); err != nil {
+		return emailReq, fmt.Errorf("invalid multipart request body: %w", err)
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	emailReq.Subject = r.FormValue("subject")
+	emailReq.Body = r.FormValue("body")
+	emailReq.HTMLBody = r.FormValue("html_body")
+	emailReq.ComplaintID = r.FormValue("complaint_id")
+
+	for _, header := range r.MultipartForm.File["attachments"] {
+		file, err := header.Open()
+		if err != nil {
+			return emailReq, fmt.Errorf("failed to read attachment %s: %w", header.Filename, err)
+		}
+		content, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return emailReq, fmt.Errorf("failed to read attachment %s: %w", header.Filename, err)
+		}
+
+		contentType := header.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(header.Filename))
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		emailReq.Attachments = append(emailReq.Attachments, AttachmentRequest{
+			Name:        header.Filename,
+			ContentType: contentType,
+			Content:     content,
+		})
+	}
+	return emailReq, nil
+}
+
+// emailAttachments converts the attachments of a request for the email message
+func emailAttachments(attachments []AttachmentRequest) []email.Attachment {
+	var result []email.Attachment
+	for _, a := range attachments {
+		result = append(result, email.Attachment{Name: a.Name, ContentType: a.ContentType, Content: a.Content})
+	}
+	return result
+}
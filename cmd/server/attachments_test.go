@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"complaint-escalator/internal/email"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+)
+
+// recordingSender records the email messages it sends
+type recordingSender struct {
+	messages []email.EmailMessage
+}
+
+func (s *recordingSender) SendEmail(ctx context.Context, msg email.EmailMessage) (string, error) {
+	s.messages = append(s.messages, msg)
+	return "op-1", nil
+}
+
+func TestSendEmailHandler_JSONAttachments(t *testing.T) {
+	server := newTestServer(t)
+	sender := &recordingSender{}
+	server.emailSender = sender
+
+	// Create request with a base64 encoded attachment
+	jsonData, err := json.Marshal(EmailRequest{
+		Subject: "Test Subject",
+		Body:    "Test Body",
+		Attachments: []AttachmentRequest{
+			{Name: "receipt.pdf", ContentType: "application/pdf", Content: []byte("%PDF-1.4")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(jsonData, []byte(`"content":"JVBERi0xLjQ="`)) {
+		t.Errorf("Expected base64 content in JSON, got %s", jsonData)
+	}
+
+	req := httptest.NewRequest("POST", "/email/send", bytes.NewReader(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	server.sendEmailHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if len(sender.messages) != 1 {
+		t.Fatalf("Expected 1 email, got %d", len(sender.messages))
+	}
+	attachments := sender.messages[0].Attachments
+	if len(attachments) != 1 || attachments[0].Name != "receipt.pdf" || string(attachments[0].Content) != "%PDF-1.4" {
+		t.Errorf("Unexpected attachments %+v", attachments)
+	}
+}
+
+func TestSendEmailHandler_MultipartUpload(t *testing.T) {
+	server := newTestServer(t)
+	sender := &recordingSender{}
+	server.emailSender = sender
+
+	// Create multipart request with two files
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("subject", "Test Subject")
+	mw.WriteField("body", "Test Body")
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="attachments"; filename="photo.png"`)
+	header.Set("Content-Type", "image/png")
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte{0x89, 'P', 'N', 'G'})
+
+	// Without a content type the type is derived from the extension
+	header = textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="attachments"; filename="notes.txt"`)
+	part, err = mw.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte("order #1234"))
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/email/send", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rr := httptest.NewRecorder()
+	server.sendEmailHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if len(sender.messages) != 1 {
+		t.Fatalf("Expected 1 email, got %d", len(sender.messages))
+	}
+	msg := sender.messages[0]
+	if msg.Subject != "Test Subject" || msg.Body != "Test Body" {
+		t.Errorf("Unexpected subject %q and body %q", msg.Subject, msg.Body)
+	}
+	if len(msg.Attachments) != 2 {
+		t.Fatalf("Expected 2 attachments, got %d", len(msg.Attachments))
+	}
+	if msg.Attachments[0].Name != "photo.png" || msg.Attachments[0].ContentType != "image/png" {
+		t.Errorf("Unexpected attachment %s of type %s", msg.Attachments[0].Name, msg.Attachments[0].ContentType)
+	}
+	if msg.Attachments[1].Name != "notes.txt" || msg.Attachments[1].ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("Unexpected attachment %s of type %s", msg.Attachments[1].Name, msg.Attachments[1].ContentType)
+	}
+}
+
+func TestSendEmailHandler_InvalidMultipart(t *testing.T) {
+	server := newTestServer(t)
+
+	req := httptest.NewRequest("POST", "/email/send", bytes.NewBufferString("not multipart"))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=missing")
+	rr := httptest.NewRecorder()
+	server.sendEmailHandler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
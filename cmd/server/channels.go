@@ -0,0 +1,84 @@
+package main
+
+import (
+	"complaint-escalator/internal/config"
+	"complaint-escalator/internal/email"
+	"complaint-escalator/internal/notification"
+	"complaint-escalator/internal/sms"
+	"fmt"
+)
+
+// newRegistry registers a notifier for every supported channel. Channels that
+// need their own settings are only available when they are configured.
+func newRegistry(cfg config.Config, emailSender email.Sender) (*notification.Registry, error) {
+	registry := notification.NewRegistry()
+	emailNotifier, err := notification.NewEmailNotifier(emailSender, cfg.FromEmail(), cfg.FollowUpSubject)
+	if err != nil {
+		return nil, fmt.Errorf("email: %w", err)
+	}
+	registry.Register("email", emailNotifier)
+	registry.Register("notification", notification.LogNotifier{})
+
+	if cfg.Slack.WebhookURL != "" {
+		slack, err := notification.NewSlackNotifier(cfg.Slack.WebhookURL)
+		if err != nil {
+			return nil, fmt.Errorf("slack: %w", err)
+		}
+		registry.Register("slack", slack)
+	}
+
+	if cfg.Telegram.BotToken != "" {
+		telegram, err := notification.NewTelegramNotifier(cfg.Telegram.APIURL, cfg.Telegram.BotToken, cfg.Telegram.ChatIDs)
+		if err != nil {
+			return nil, fmt.Errorf("telegram: %w", err)
+		}
+		registry.Register("telegram", telegram)
+	}
+
+	if cfg.Teams.WebhookURL != "" {
+		teams, err := notification.NewTeamsNotifier(cfg.Teams.WebhookURL)
+		if err != nil {
+			return nil, fmt.Errorf("teams: %w", err)
+		}
+		registry.Register("teams", teams)
+	}
+
+	if cfg.Discord.WebhookURL != "" {
+		discord, err := notification.NewDiscordNotifier(cfg.Discord.WebhookURL)
+		if err != nil {
+			return nil, fmt.Errorf("discord: %w", err)
+		}
+		registry.Register("discord", discord)
+	}
+
+	if cfg.SMS.From != "" {
+		smsClient, err := sms.NewSMSClient(cfg.ACS.ConnectionString)
+		if err != nil {
+			return nil, fmt.Errorf("sms: %w", err)
+		}
+		notifier, err := notification.NewSMSNotifier(smsClient, cfg.SMS.From, cfg.SMS.To, cfg.SMS.MaxSegments, cfg.SMS.Truncate)
+		if err != nil {
+			return nil, fmt.Errorf("sms: %w", err)
+		}
+		registry.Register("sms", notifier)
+	}
+
+	if len(cfg.Webhook.URLs) > 0 {
+		webhook, err := notification.NewWebhookNotifier(cfg.Webhook.URLs, cfg.Webhook.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("webhook: %w", err)
+		}
+		registry.Register("webhook", webhook)
+	}
+	return registry, nil
+}
+
+// validateChannels checks that a notifier is registered for every channel
+func (s *Server) validateChannels(channels []string) error {
+	for _, channel := range channels {
+		if _, ok := s.registry.Get(channel); !ok {
+			return fmt.Errorf("%w: %s (available: %v)", notification.ErrUnknownChannel, channel, s.registry.Channels())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"complaint-escalator/internal/complaint"
+	"complaint-escalator/internal/notification"
+	"complaint-escalator/internal/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ComplaintRequest represents the JSON request structure for creating a complaint
+type ComplaintRequest struct {
+	ID         string               `json:"id,omitempty"`
+	Subject    string               `json:"subject"`
+	Template   string               `json:"template"`
+	Recipients complaint.Recipients `json:"recipients"`
+	Channels   []string             `json:"channels"`
+	// Escalate starts escalating the complaint right away instead of leaving it open
+	Escalate bool `json:"escalate,omitempty"`
+}
+
+// ComplaintUpdateRequest represents the JSON request structure for updating a complaint.
+// Only the provided fields are changed.
+type ComplaintUpdateRequest struct {
+	Subject    *string               `json:"subject,omitempty"`
+	Template   *string               `json:"template,omitempty"`
+	Recipients *complaint.Recipients `json:"recipients,omitempty"`
+	Channels   []string              `json:"channels,omitempty"`
+}
+
+// ComplaintResponse represents the JSON response structure for complaint endpoints
+type ComplaintResponse struct {
+	Success    bool                  `json:"success"`
+	Message    string                `json:"message"`
+	Complaint  *complaint.Complaint  `json:"complaint,omitempty"`
+	Complaints []complaint.Complaint `json:"complaints,omitempty"`
+}
+
+// AttemptsResponse represents the JSON response structure for the send history of a complaint
+type AttemptsResponse struct {
+	Success  bool              `json:"success"`
+	Message  string            `json:"message"`
+	Attempts []storage.Attempt `json:"attempts"`
+}
+
+// complaintsHandler handles listing and creating complaints
+func (s *Server) complaintsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		complaints, err := s.complaints.ListComplaints()
+		if err != nil {
+			writeComplaintError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		writeComplaintResponse(w, http.StatusOK, ComplaintResponse{
+			Success:    true,
+			Message:    fmt.Sprintf("%d complaints", len(complaints)),
+			Complaints: complaints,
+		})
+	case http.MethodPost:
+		s.createComplaint(w, r)
+	default:
+		writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+// createComplaint creates a new complaint and optionally starts escalating it
+func (s *Server) createComplaint(w http.ResponseWriter, r *http.Request) {
+	var req ComplaintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r, "Invalid JSON request body", err)
+		return
+	}
+	if len(req.Channels) == 0 {
+		req.Channels = s.config.Channels
+	}
+
+	now := time.Now()
+	c, err := complaint.New(req.ID, req.Subject, req.Template, req.Recipients, req.Channels, now)
+	if err != nil {
+		writeComplaintError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.validateChannels(c.Channels); err != nil {
+		writeComplaintError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if req.Escalate {
+		if err := c.Transition(complaint.StateEscalating, now); err != nil {
+			writeComplaintError(w, r, http.StatusConflict, err)
+			return
+		}
+	}
+
+	if err := s.complaints.AddComplaint(c); err != nil {
+		writeComplaintError(w, r, complaintErrorStatus(err), err)
+		return
+	}
+	s.syncSchedule(c)
+
+	writeComplaintResponse(w, http.StatusCreated, ComplaintResponse{
+		Success:   true,
+		Message:   "Complaint created",
+		Complaint: &c,
+	})
+}
+
+// complaintHandler handles reading and updating a single complaint
+func (s *Server) complaintHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	switch r.Method {
+	case http.MethodGet:
+		c, err := s.complaints.GetComplaint(id)
+		if err != nil {
+			writeComplaintError(w, r, complaintErrorStatus(err), err)
+			return
+		}
+		writeComplaintResponse(w, http.StatusOK, ComplaintResponse{
+			Success:   true,
+			Message:   "Complaint found",
+			Complaint: &c,
+		})
+	case http.MethodPut, http.MethodPatch:
+		s.updateComplaint(w, r, id)
+	default:
+		writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodPatch)
+	}
+}
+
+// updateComplaint changes the content or recipients of a complaint that is still active
+func (s *Server) updateComplaint(w http.ResponseWriter, r *http.Request, id string) {
+	var req ComplaintUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r, "Invalid JSON request body", err)
+		return
+	}
+
+	c, err := s.complaints.UpdateComplaint(id, func(c *complaint.Complaint) error {
+		if c.State.Terminal() {
+			return fmt.Errorf("%w: complaint is %s", complaint.ErrInvalidTransition, c.State)
+		}
+		if req.Subject != nil {
+			c.Subject = *req.Subject
+		}
+		if req.Template != nil {
+			c.Template = *req.Template
+		}
+		if req.Recipients != nil {
+			c.Recipients = *req.Recipients
+		}
+		if req.Channels != nil {
+			c.Channels = req.Channels
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if err := s.validateChannels(c.Channels); err != nil {
+			return err
+		}
+		c.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		writeComplaintError(w, r, complaintErrorStatus(err), err)
+		return
+	}
+
+	writeComplaintResponse(w, http.StatusOK, ComplaintResponse{
+		Success:   true,
+		Message:   "Complaint updated",
+		Complaint: &c,
+	})
+}
+
+// transitionHandler returns a handler that moves a complaint to the given state
+func (s *Server) transitionHandler(to complaint.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeMethodNotAllowed(w, r, http.MethodPost)
+			return
+		}
+
+		c, err := s.complaints.UpdateComplaint(r.PathValue("id"), func(c *complaint.Complaint) error {
+			return c.Transition(to, time.Now())
+		})
+		if err != nil {
+			writeComplaintError(w, r, complaintErrorStatus(err), err)
+			return
+		}
+		s.syncSchedule(c)
+
+		log.Printf("Complaint %s is now %s", c.ID, c.State)
+		writeComplaintResponse(w, http.StatusOK, ComplaintResponse{
+			Success:   true,
+			Message:   fmt.Sprintf("Complaint %s", c.State),
+			Complaint: &c,
+		})
+	}
+}
+
+// attemptsHandler returns the send history of a complaint
+func (s *Server) attemptsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	id := r.PathValue("id")
+	if _, err := s.complaints.GetComplaint(id); err != nil {
+		writeComplaintError(w, r, complaintErrorStatus(err), err)
+		return
+	}
+	attempts, err := s.complaints.ListAttempts(id)
+	if err != nil {
+		writeComplaintError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(AttemptsResponse{
+		Success:  true,
+		Message:  fmt.Sprintf("%d attempts", len(attempts)),
+		Attempts: attempts,
+	})
+}
+
+// syncSchedule schedules escalating complaints and unschedules all others
+func (s *Server) syncSchedule(c complaint.Complaint) {
+	if s.scheduler == nil {
+		return
+	}
+	if c.State == complaint.StateEscalating {
+		s.scheduler.Schedule(c.ID, s.firstSend(c.ID))
+		return
+	}
+	s.scheduler.Unschedule(c.ID)
+}
+
+// complaintErrorStatus maps complaint errors to HTTP status codes. Errors that are not
+// the fault of the request, such as storage failures, are internal errors.
+func complaintErrorStatus(err error) int {
+	var validationErr *complaint.ValidationError
+	switch {
+	case errors.Is(err, complaint.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, complaint.ErrAlreadyExists), errors.Is(err, complaint.ErrInvalidTransition):
+		return http.StatusConflict
+	case errors.As(err, &validationErr), errors.Is(err, notification.ErrUnknownChannel):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeComplaintResponse writes a complaint response as JSON
+func writeComplaintResponse(w http.ResponseWriter, status int, response ComplaintResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"complaint-escalator/internal/complaint"
+	"complaint-escalator/internal/config"
+	"complaint-escalator/internal/notification"
+	"complaint-escalator/pkg/testutils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestServer creates a server from the test configuration
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	cfg, err := config.LoadConfig(testutils.GetTestConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load test configuration: %v", err)
+	}
+
+	server, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	return server
+}
+
+// doComplaintRequest sends a request through the server router and decodes the response
+func doComplaintRequest(t *testing.T, server *Server, method, path string, body interface{}) (int, ComplaintResponse) {
+	t.Helper()
+
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, path, &reqBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	server.httpServer.Handler.ServeHTTP(rr, req)
+
+	var response ComplaintResponse
+	if rr.Header().Get("Content-Type") == "application/json" {
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+	return rr.Code, response
+}
+
+func TestComplaintLifecycleAPI(t *testing.T) {
+	server := newTestServer(t)
+
+	// Create an open complaint
+	status, resp := doComplaintRequest(t, server, "POST", "/complaints", ComplaintRequest{
+		ID:         "refund",
+		Subject:    "Refund for order #42",
+		Template:   "Please refund order #42.",
+		Recipients: complaint.Recipients{To: []string{"billing@example.com"}},
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("create returned wrong status code: got %v want %v (%s)", status, http.StatusCreated, resp.Message)
+	}
+	if resp.Complaint == nil || resp.Complaint.State != complaint.StateOpen {
+		t.Fatalf("Expected an open complaint, got %+v", resp.Complaint)
+	}
+	if len(resp.Complaint.Channels) == 0 {
+		t.Error("Channels should default to the configured channels")
+	}
+	if server.scheduler.Scheduled("refund") {
+		t.Error("Open complaint should not be scheduled")
+	}
+
+	// Creating the same id twice conflicts
+	status, _ = doComplaintRequest(t, server, "POST", "/complaints", ComplaintRequest{
+		ID:       "refund",
+		Subject:  "Duplicate",
+		Template: "Duplicate",
+	})
+	if status != http.StatusConflict {
+		t.Errorf("duplicate create returned wrong status code: got %v want %v", status, http.StatusConflict)
+	}
+
+	// Resume starts the escalation
+	status, resp = doComplaintRequest(t, server, "POST", "/complaints/refund/resume", nil)
+	if status != http.StatusOK || resp.Complaint.State != complaint.StateEscalating {
+		t.Fatalf("resume failed: status %v, %+v", status, resp)
+	}
+	if !server.scheduler.Scheduled("refund") {
+		t.Error("Escalating complaint should be scheduled")
+	}
+
+	// Pause stops it again
+	status, resp = doComplaintRequest(t, server, "POST", "/complaints/refund/pause", nil)
+	if status != http.StatusOK || resp.Complaint.State != complaint.StatePaused {
+		t.Fatalf("pause failed: status %v, %+v", status, resp)
+	}
+	if server.scheduler.Scheduled("refund") {
+		t.Error("Paused complaint should not be scheduled")
+	}
+
+	// Update changes only the provided fields
+	subject := "Refund for order #42 (second request)"
+	status, resp = doComplaintRequest(t, server, "PATCH", "/complaints/refund", ComplaintUpdateRequest{Subject: &subject})
+	if status != http.StatusOK {
+		t.Fatalf("update returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if resp.Complaint.Subject != subject || resp.Complaint.Template != "Please refund order #42." {
+		t.Errorf("Unexpected updated complaint: %+v", resp.Complaint)
+	}
+
+	// Resolve is terminal
+	status, resp = doComplaintRequest(t, server, "POST", "/complaints/refund/resolve", nil)
+	if status != http.StatusOK || resp.Complaint.State != complaint.StateResolved || resp.Complaint.ResolvedAt == nil {
+		t.Fatalf("resolve failed: status %v, %+v", status, resp)
+	}
+	status, _ = doComplaintRequest(t, server, "POST", "/complaints/refund/resume", nil)
+	if status != http.StatusConflict {
+		t.Errorf("resume of resolved complaint returned wrong status code: got %v want %v", status, http.StatusConflict)
+	}
+	status, _ = doComplaintRequest(t, server, "PATCH", "/complaints/refund", ComplaintUpdateRequest{Subject: &subject})
+	if status != http.StatusConflict {
+		t.Errorf("update of resolved complaint returned wrong status code: got %v want %v", status, http.StatusConflict)
+	}
+
+	// Get returns the stored complaint
+	status, resp = doComplaintRequest(t, server, "GET", "/complaints/refund", nil)
+	if status != http.StatusOK || resp.Complaint.ID != "refund" {
+		t.Errorf("get failed: status %v, %+v", status, resp)
+	}
+
+	// List includes the default complaint from the configuration
+	status, resp = doComplaintRequest(t, server, "GET", "/complaints", nil)
+	if status != http.StatusOK || len(resp.Complaints) != 2 {
+		t.Errorf("list failed: status %v, %d complaints", status, len(resp.Complaints))
+	}
+}
+
+func TestComplaintAPIErrors(t *testing.T) {
+	server := newTestServer(t)
+
+	// Missing fields are rejected
+	status, resp := doComplaintRequest(t, server, "POST", "/complaints", ComplaintRequest{Subject: "No template"})
+	if status != http.StatusBadRequest || resp.Success {
+		t.Errorf("create returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	// Channels must be registered
+	status, resp = doComplaintRequest(t, server, "POST", "/complaints", ComplaintRequest{
+		Subject:  "Unknown channel",
+		Template: "Template",
+		Channels: []string{"carrier-pigeon"},
+	})
+	if status != http.StatusBadRequest || resp.Success {
+		t.Errorf("create returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	// Unknown complaints are not found
+	status, _ = doComplaintRequest(t, server, "GET", "/complaints/missing", nil)
+	if status != http.StatusNotFound {
+		t.Errorf("get returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+	status, _ = doComplaintRequest(t, server, "POST", "/complaints/missing/pause", nil)
+	if status != http.StatusNotFound {
+		t.Errorf("pause returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+
+	// Transitions require POST
+	status, _ = doComplaintRequest(t, server, "GET", "/complaints/default/pause", nil)
+	if status != http.StatusMethodNotAllowed {
+		t.Errorf("pause returned wrong status code: got %v want %v", status, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestComplaintErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"not found", fmt.Errorf("%w: c1", complaint.ErrNotFound), http.StatusNotFound},
+		{"already exists", complaint.ErrAlreadyExists, http.StatusConflict},
+		{"invalid transition", fmt.Errorf("%w: resolved -> paused", complaint.ErrInvalidTransition), http.StatusConflict},
+		{"validation", &complaint.ValidationError{Fields: []complaint.FieldError{{Field: "subject", Code: "required", Message: "subject is required"}}}, http.StatusBadRequest},
+		{"unknown channel", fmt.Errorf("%w: carrier-pigeon", notification.ErrUnknownChannel), http.StatusBadRequest},
+		{"storage failure", errors.New("input/output error"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if status := complaintErrorStatus(tt.err); status != tt.status {
+			t.Errorf("%s: got %v want %v", tt.name, status, tt.status)
+		}
+	}
+}
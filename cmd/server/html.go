@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"complaint-escalator/internal/complaint"
+	"complaint-escalator/internal/storage"
+	"html/template"
+	"log"
+	"strings"
+	"time"
+)
+
+// htmlTemplateData is the data available to the HTML email template
+type htmlTemplateData struct {
+	Subject string
+	// Text is the generated complaint text, and Paragraphs the same text split at blank lines
+	Text       string
+	Paragraphs []string
+	// Attempt is the number of the escalation round being sent
+	Attempt   int
+	Complaint complaint.Complaint
+	// Attempts is the send history of the complaint, oldest first
+	Attempts []storage.Attempt
+}
+
+// htmlTemplateFuncs are the helper functions available to the HTML email template
+var htmlTemplateFuncs = template.FuncMap{
+	"datetime": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04 MST")
+	},
+}
+
+// parseHTMLTemplate parses the configured HTML email template. An empty text means no template.
+func parseHTMLTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	return template.New("email").Funcs(htmlTemplateFuncs).Parse(text)
+}
+
+// renderHTML renders the HTML body of a complaint email. It returns an empty string
+// when no template is configured or rendering fails, so the email goes out as plaintext.
+func (s *Server) renderHTML(c complaint.Complaint, subject, text string, attempt int) string {
+	if s.htmlTemplate == nil {
+		return ""
+	}
+
+	data := htmlTemplateData{
+		Subject:    subject,
+		Text:       text,
+		Paragraphs: paragraphs(text),
+		Attempt:    attempt,
+		Complaint:  c,
+	}
+	if s.complaints != nil {
+		attempts, err := s.complaints.ListAttempts(c.ID)
+		if err != nil {
+			log.Printf("Failed to load attempts of complaint %s for the HTML body: %v", c.ID, err)
+		}
+		data.Attempts = attempts
+	}
+
+	var buf bytes.Buffer
+	if err := s.htmlTemplate.Execute(&buf, data); err != nil {
+		log.Printf("Failed to render HTML body of complaint %s: %v", c.ID, err)
+		return ""
+	}
+	return buf.String()
+}
+
+// paragraphs splits text at blank lines
+func paragraphs(text string) []string {
+	var result []string
+	for _, p := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
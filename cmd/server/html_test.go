@@ -0,0 +1,68 @@
+package main
+
+import (
+	"complaint-escalator/internal/notification"
+	"context"
+	"strings"
+	"testing"
+)
+
+// testHTMLTemplate lists the prior attempts of a complaint in a table
+const testHTMLTemplate = `<h1>{{.Subject}}</h1>
+{{range .Paragraphs}}<p>{{.}}</p>{{end}}
+<table>{{range .Attempts}}<tr><td>{{.Number}}</td><td>{{.Channel}}</td><td>{{datetime .SentAt}}</td></tr>{{end}}</table>`
+
+// recordingNotifier records the messages it is asked to send
+type recordingNotifier struct {
+	messages []notification.Message
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg notification.Message) (notification.Receipt, error) {
+	n.messages = append(n.messages, msg)
+	return notification.Receipt{ID: "receipt"}, nil
+}
+
+func TestEscalateRendersHTMLTemplate(t *testing.T) {
+	server := newTestServer(t)
+
+	var err error
+	server.htmlTemplate, err = parseHTMLTemplate(testHTMLTemplate)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	email := &recordingNotifier{}
+	server.registry.Register("email", email)
+	server.registry.Register("notification", &recordingNotifier{})
+	server.poller = nil
+
+	for i := 0; i < 2; i++ {
+		if err := server.escalate(context.Background(), "default"); err != nil {
+			t.Fatalf("Failed to escalate: %v", err)
+		}
+	}
+
+	if len(email.messages) != 2 {
+		t.Fatalf("Expected 2 emails, got %d", len(email.messages))
+	}
+	first, second := email.messages[0].HTML, email.messages[1].HTML
+	if !strings.Contains(first, "<h1>Test complaint subject</h1>") || !strings.Contains(first, "<p>Test complaint template for automated testing.</p>") {
+		t.Errorf("Unexpected HTML body: %s", first)
+	}
+	if strings.Count(first, "<tr>") != 0 {
+		t.Errorf("First email should have no prior attempts: %s", first)
+	}
+
+	// The second round lists the attempts of the first, one per channel
+	if strings.Count(second, "<tr>") != 2 || !strings.Contains(second, "<td>1</td><td>email</td>") {
+		t.Errorf("Second email should list the prior attempts: %s", second)
+	}
+}
+
+func TestParseHTMLTemplate(t *testing.T) {
+	if tmpl, err := parseHTMLTemplate(""); tmpl != nil || err != nil {
+		t.Errorf("Expected no template, got %v, %v", tmpl, err)
+	}
+	if _, err := parseHTMLTemplate("{{.Subject"); err == nil {
+		t.Error("Expected error for invalid template")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"complaint-escalator/internal/complaint"
+	"complaint-escalator/internal/config"
+	"complaint-escalator/internal/inbound"
+	"complaint-escalator/internal/notification"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxInboundRequestSize limits the body of an inbound email delivery
+	maxInboundRequestSize = 1 << 20
+	// inboundSignatureTolerance is how old the signature of an inbound delivery may be
+	inboundSignatureTolerance = 5 * time.Minute
+)
+
+// InboundResponse represents the JSON response to an inbound email delivery
+type InboundResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	// Matched lists the complaints that received a reply
+	Matched []string `json:"matched"`
+}
+
+// newMatcher creates the inbound reply matcher, or returns nil when inbound replies are not configured
+func newMatcher(cfg config.Config) (*inbound.Matcher, error) {
+	if cfg.Inbound.Secret == "" && cfg.Inbound.ReplyAddress == "" {
+		return nil, nil
+	}
+	return inbound.NewMatcher(cfg.FromEmail(), cfg.Inbound.ReplyAddress)
+}
+
+// replyTo returns the reply-to address of a complaint's emails: its own, or else the
+// inbound reply address tagged with its id
+func (s *Server) replyTo(c complaint.Complaint) string {
+	if c.Recipients.ReplyTo != "" || s.matcher == nil {
+		return c.Recipients.ReplyTo
+	}
+	return s.matcher.ReplyAddress(c.ID)
+}
+
+// inboundEmailHandler receives inbound emails posted by a mail relay. Deliveries are
+// signed like outbound webhooks, with the inbound secret.
+func (s *Server) inboundEmailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+
+	secret := s.config.Inbound.Secret
+	if s.matcher == nil || secret == "" {
+		writeError(w, r, http.StatusNotImplemented, CodeNotImplemented, "Inbound email is not configured")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInboundRequestSize))
+	if err != nil {
+		writeInvalidBody(w, r, "Failed to read request body", err)
+		return
+	}
+	signature := r.Header.Get(notification.WebhookSignatureHeader)
+	if err := notification.VerifyWebhook(secret, signature, body, inboundSignatureTolerance, time.Now()); err != nil {
+		writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid signature")
+		return
+	}
+	replies, err := inbound.ParseWebhook(body)
+	if err != nil {
+		writeInvalidBody(w, r, fmt.Sprintf("Invalid inbound email request body: %v", err), err)
+		return
+	}
+
+	// Unmatched replies are acknowledged too, so the relay does not redeliver them
+	matched := []string{}
+	for _, reply := range replies {
+		if id, ok := s.handleReply(reply); ok {
+			matched = append(matched, id)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(InboundResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d of %d replies matched", len(matched), len(replies)),
+		Matched: matched,
+	})
+}
+
+// handleReply moves the complaint a reply answers to the responded state, which stops
+// its escalation until someone resumes, resolves or abandons it
+func (s *Server) handleReply(reply inbound.Reply) (string, bool) {
+	target, ok := s.matcher.Match(reply)
+	if !ok {
+		log.Printf("Inbound email %s from %s matches no complaint", reply.MessageID, reply.From)
+		return "", false
+	}
+	id := target.ComplaintID
+
+	now := reply.ReceivedAt
+	if now.IsZero() {
+		now = time.Now()
+	}
+	c, err := s.complaints.UpdateComplaint(id, func(c *complaint.Complaint) error {
+		if !target.Answers(c.CreatedAt) {
+			return fmt.Errorf("reply belongs to the thread of an earlier complaint with this id")
+		}
+		// Further replies only move the time of the last one
+		if c.State == complaint.StateResponded {
+			c.RespondedAt = &now
+			c.UpdatedAt = now
+			return nil
+		}
+		return c.Transition(complaint.StateResponded, now)
+	})
+	if err != nil {
+		log.Printf("Ignoring reply from %s to complaint %s: %v", reply.From, id, err)
+		return "", false
+	}
+	s.syncSchedule(c)
+
+	log.Printf("Complaint %s received a reply from %s and is now %s", c.ID, reply.From, c.State)
+	return c.ID, true
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"complaint-escalator/internal/complaint"
+	"complaint-escalator/internal/email"
+	"complaint-escalator/internal/inbound"
+	"complaint-escalator/internal/notification"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newInboundTestServer creates a test server with inbound replies enabled
+func newInboundTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	server := newTestServer(t)
+	server.config.Inbound.Secret = "secret"
+	server.config.Inbound.ReplyAddress = "replies@test-domain.dev"
+	matcher, err := newMatcher(*server.config)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	server.matcher = matcher
+	return server
+}
+
+// postInbound delivers emails to the inbound webhook, signed with secret at the given time
+func postInbound(t *testing.T, server *Server, secret string, at time.Time, emails []map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	jsonData, err := json.Marshal(emails)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/inbound/email", bytes.NewReader(jsonData))
+	req.Header.Set(notification.WebhookSignatureHeader, notification.SignWebhook(secret, at, jsonData))
+	rr := httptest.NewRecorder()
+	server.inboundEmailHandler(rr, req)
+	return rr
+}
+
+func TestInboundEmailHandlerSignature(t *testing.T) {
+	server := newInboundTestServer(t)
+
+	tests := []struct {
+		name   string
+		secret string
+		at     time.Time
+		status int
+	}{
+		{"valid", "secret", time.Now(), http.StatusOK},
+		{"wrong secret", "wrong", time.Now(), http.StatusUnauthorized},
+		{"replayed", "secret", time.Now().Add(-time.Hour), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := postInbound(t, server, tt.secret, tt.at, nil); rr.Code != tt.status {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.status)
+			}
+		})
+	}
+
+	// Deliveries without a signature are rejected
+	req := httptest.NewRequest("POST", "/inbound/email", bytes.NewReader([]byte("[]")))
+	rr := httptest.NewRecorder()
+	server.inboundEmailHandler(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestInboundEmailHandlerMarksComplaintResponded(t *testing.T) {
+	server := newInboundTestServer(t)
+
+	c, err := server.complaints.GetComplaint("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.State != complaint.StateEscalating || !server.scheduler.Scheduled(c.ID) {
+		t.Fatalf("Expected the default complaint to be escalating, got %s", c.State)
+	}
+
+	// Emails to the complaint go out with the tagged reply address, unless it has its own
+	c.Recipients.ReplyTo = ""
+	if replyTo := server.replyTo(c); replyTo != "replies+default@test-domain.dev" {
+		t.Errorf("Expected tagged reply address, got %q", replyTo)
+	}
+
+	rr := postInbound(t, server, "secret", time.Now(), []map[string]interface{}{{
+		"from":       "support@shop.example",
+		"to":         []string{"test@test-domain.dev"},
+		"subject":    "Re: Test complaint subject",
+		"inReplyTo":  "<acs-generated@azurecomm.net>",
+		"references": fmt.Sprintf("%s <acs-generated@azurecomm.net>", server.threadID(c)),
+		"receivedAt": "2025-01-01T09:00:00Z",
+	}, {
+		"from":    "newsletter@shop.example",
+		"to":      []string{"test@test-domain.dev"},
+		"subject": "Our spring sale",
+	}, {
+		"from":      "support@shop.example",
+		"to":        []string{"test@test-domain.dev"},
+		"subject":   "Re: An older complaint",
+		"inReplyTo": email.ThreadRootID(inbound.ThreadKey(c.ID, c.CreatedAt.Add(-time.Hour)), server.config.FromEmail()),
+	}})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	var response InboundResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	// The reply to an earlier complaint with the same id does not count
+	if len(response.Matched) != 1 || response.Matched[0] != "default" {
+		t.Errorf("Expected only the first reply to match complaint default, got %v", response.Matched)
+	}
+
+	c, err = server.complaints.GetComplaint("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.State != complaint.StateResponded || c.RespondedAt == nil {
+		t.Errorf("Expected complaint to be responded, got %s", c.State)
+	}
+	if server.scheduler.Scheduled(c.ID) {
+		t.Error("Responded complaint should no longer be scheduled")
+	}
+
+	// A human decides to keep escalating
+	req := httptest.NewRequest("POST", "/complaints/default/resume", nil)
+	req.SetPathValue("id", "default")
+	rr = httptest.NewRecorder()
+	server.transitionHandler(complaint.StateEscalating)(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected responded complaint to resume, got status %d", rr.Code)
+	}
+}
+
+func TestInboundEmailHandlerNotConfigured(t *testing.T) {
+	server := newTestServer(t)
+
+	if rr := postInbound(t, server, "", time.Now(), nil); rr.Code != http.StatusNotImplemented {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotImplemented)
+	}
+}
@@ -2,7 +2,14 @@ package main
 
 import (
 	"complaint-escalator/internal/config"
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
@@ -15,5 +22,21 @@ func main() {
 	if err != nil {
 		panic(fmt.Sprintf("failed to start server: %v", err))
 	}
-	server.Start()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server.StartScheduler(ctx)
+
+	go func() {
+		if err := server.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("HTTP server failed: %v", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	if err := server.Stop(); err != nil {
+		log.Printf("Failed to stop server: %v", err)
+	}
 }
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"complaint-escalator/internal/complaint"
+	"complaint-escalator/internal/email"
+	"complaint-escalator/internal/notification"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// problemContentType is the media type of error responses (RFC 7807)
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix prefixes the code of a problem to form its type URI
+const problemTypePrefix = "urn:complaint-escalator:problem:"
+
+// ProblemCode is the machine-readable reason of an error response
+type ProblemCode string
+
+const (
+	// CodeMethodNotAllowed means the endpoint does not support the method. The Allow header lists those it does.
+	CodeMethodNotAllowed ProblemCode = "method_not_allowed"
+	// CodeInvalidBody means the request body could not be decoded
+	CodeInvalidBody ProblemCode = "invalid_body"
+	// CodeValidationFailed means request fields are invalid. Errors lists them.
+	CodeValidationFailed ProblemCode = "validation_failed"
+	// CodeInvalidRequest means the request cannot be handled for another reason
+	CodeInvalidRequest ProblemCode = "invalid_request"
+	// CodeNotFound means the complaint, email operation or endpoint does not exist
+	CodeNotFound ProblemCode = "not_found"
+	// CodeAlreadyExists means a complaint with the same id exists
+	CodeAlreadyExists ProblemCode = "already_exists"
+	// CodeInvalidTransition means the complaint cannot move to the requested state
+	CodeInvalidTransition ProblemCode = "invalid_transition"
+	// CodeUnauthorized means the request did not carry valid credentials
+	CodeUnauthorized ProblemCode = "unauthorized"
+	// CodeNotImplemented means the feature is not configured or not supported by the email transport
+	CodeNotImplemented ProblemCode = "not_implemented"
+	// CodeEmailRejected means ACS rejected a recipient of the email
+	CodeEmailRejected ProblemCode = "email_rejected"
+	// CodeEmailThrottled means ACS is rate limiting. The Retry-After header says how long to wait.
+	CodeEmailThrottled ProblemCode = "email_throttled"
+	// CodeEmailUnavailable means ACS failed or refused the credentials or request of the service
+	CodeEmailUnavailable ProblemCode = "email_unavailable"
+	// CodeEmailFailed means the email could not be sent for another reason
+	CodeEmailFailed ProblemCode = "email_failed"
+	// CodeInternal means the service failed
+	CodeInternal ProblemCode = "internal_error"
+)
+
+// problemTitles are the short, fixed summaries of each problem code
+var problemTitles = map[ProblemCode]string{
+	CodeMethodNotAllowed:  "Method not allowed",
+	CodeInvalidBody:       "Invalid request body",
+	CodeValidationFailed:  "Validation failed",
+	CodeInvalidRequest:    "Invalid request",
+	CodeNotFound:          "Not found",
+	CodeAlreadyExists:     "Already exists",
+	CodeInvalidTransition: "Invalid state transition",
+	CodeUnauthorized:      "Unauthorized",
+	CodeNotImplemented:    "Not implemented",
+	CodeEmailRejected:     "Email rejected",
+	CodeEmailThrottled:    "Email sending throttled",
+	CodeEmailUnavailable:  "Email service unavailable",
+	CodeEmailFailed:       "Email sending failed",
+	CodeInternal:          "Internal server error",
+}
+
+// Problem represents an RFC 7807 problem details error response
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code is the machine-readable reason, also the last segment of Type
+	Code ProblemCode `json:"code"`
+	// Errors lists the invalid fields of a request that failed validation
+	Errors []FieldProblem `json:"errors,omitempty"`
+}
+
+// FieldProblem describes why a single request field is invalid
+type FieldProblem struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// newProblem creates a problem for the request
+func newProblem(r *http.Request, status int, code ProblemCode, detail string) Problem {
+	return Problem{
+		Type:     problemTypePrefix + string(code),
+		Title:    problemTitles[code],
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	}
+}
+
+// writeProblem writes a problem details response
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// writeError writes a problem details response with the given status, code and detail
+func writeError(w http.ResponseWriter, r *http.Request, status int, code ProblemCode, detail string) {
+	writeProblem(w, newProblem(r, status, code, detail))
+}
+
+// writeMethodNotAllowed answers a request with an unsupported method
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, fmt.Sprintf("Method %s is not allowed, use %s", r.Method, strings.Join(allowed, " or ")))
+}
+
+// writeValidationError answers a request whose fields are invalid
+func writeValidationError(w http.ResponseWriter, r *http.Request, fields ...FieldProblem) {
+	problem := newProblem(r, http.StatusBadRequest, CodeValidationFailed, "")
+	details := make([]string, len(fields))
+	for i, f := range fields {
+		details[i] = f.Detail
+	}
+	problem.Detail = strings.Join(details, "; ")
+	problem.Errors = fields
+	writeProblem(w, problem)
+}
+
+// writeInvalidBody answers a request whose body could not be decoded. A JSON value of
+// the wrong type is reported as a field problem.
+func writeInvalidBody(w http.ResponseWriter, r *http.Request, detail string, err error) {
+	problem := newProblem(r, http.StatusBadRequest, CodeInvalidBody, detail)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		problem.Errors = []FieldProblem{{
+			Field:  typeErr.Field,
+			Code:   "invalid_type",
+			Detail: fmt.Sprintf("%s must be %s, not %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind().String()), typeErr.Value),
+		}}
+	}
+	writeProblem(w, problem)
+}
+
+// jsonTypeName names a Go kind the way JSON clients know it
+func jsonTypeName(kind string) string {
+	switch {
+	case kind == "slice" || kind == "array":
+		return "an array"
+	case kind == "struct" || kind == "map":
+		return "an object"
+	case kind == "bool":
+		return "a boolean"
+	case strings.HasPrefix(kind, "int") || strings.HasPrefix(kind, "uint") || strings.HasPrefix(kind, "float"):
+		return "a number"
+	default:
+		return "a " + kind
+	}
+}
+
+// writeComplaintError answers a failed complaint request. Validation errors list their
+// fields and the complaint sentinel errors get their own codes.
+func writeComplaintError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	var validationErr *complaint.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		fields := make([]FieldProblem, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			fields[i] = FieldProblem{Field: f.Field, Code: f.Code, Detail: f.Message}
+		}
+		writeValidationError(w, r, fields...)
+	case errors.Is(err, notification.ErrUnknownChannel):
+		writeValidationError(w, r, FieldProblem{Field: "channels", Code: "unknown_channel", Detail: err.Error()})
+	case errors.Is(err, complaint.ErrNotFound):
+		writeError(w, r, status, CodeNotFound, err.Error())
+	case errors.Is(err, complaint.ErrAlreadyExists):
+		writeError(w, r, status, CodeAlreadyExists, err.Error())
+	case errors.Is(err, complaint.ErrInvalidTransition):
+		writeError(w, r, status, CodeInvalidTransition, err.Error())
+	case status >= http.StatusInternalServerError:
+		writeError(w, r, status, CodeInternal, err.Error())
+	default:
+		writeError(w, r, status, CodeInvalidRequest, err.Error())
+	}
+}
+
+// writeEmailError answers a failed email send, telling client errors, throttling and
+// failures of the email service apart
+func writeEmailError(w http.ResponseWriter, r *http.Request, err error) {
+	detail := fmt.Sprintf("Failed to send email: %v", err)
+
+	var apiErr *email.APIError
+	if !errors.As(err, &apiErr) {
+		writeError(w, r, http.StatusInternalServerError, CodeEmailFailed, detail)
+		return
+	}
+
+	switch apiErr.Kind {
+	case email.ErrorInvalidRecipient:
+		problem := newProblem(r, http.StatusUnprocessableEntity, CodeEmailRejected, detail)
+		problem.Errors = []FieldProblem{{Field: "recipients", Code: "invalid", Detail: apiErr.Message}}
+		writeProblem(w, problem)
+	case email.ErrorThrottled:
+		if apiErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(apiErr.RetryAfter.Seconds()))))
+		}
+		writeError(w, r, http.StatusServiceUnavailable, CodeEmailThrottled, detail)
+	default:
+		writeError(w, r, http.StatusBadGateway, CodeEmailUnavailable, detail)
+	}
+}
+
+// notFoundHandler answers requests for unknown paths
+func (s *Server) notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, CodeNotFound, fmt.Sprintf("No endpoint at %s", r.URL.Path))
+}
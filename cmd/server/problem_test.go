@@ -0,0 +1,129 @@
+package main
+
+import (
+	"complaint-escalator/internal/email"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// doProblemRequest sends a raw request through the server router and decodes the problem it answers with
+func doProblemRequest(t *testing.T, server *Server, method, path, body string) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	server.httpServer.Handler.ServeHTTP(rr, req)
+
+	if ct := rr.Header().Get("Content-Type"); ct != problemContentType {
+		t.Fatalf("Expected content type %s, got %s", problemContentType, ct)
+	}
+	var problem Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	if problem.Status != rr.Code || problem.Type != problemTypePrefix+string(problem.Code) || problem.Title == "" {
+		t.Errorf("Inconsistent problem for status %d: %+v", rr.Code, problem)
+	}
+	return rr, problem
+}
+
+func TestProblemResponses(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   ProblemCode
+		fields []string
+	}{
+		{"missing fields", "POST", "/complaints", `{"id":"Bad ID!"}`, http.StatusBadRequest, CodeValidationFailed, []string{"id", "subject", "template"}},
+		{"unknown channel", "POST", "/complaints", `{"subject":"s","template":"t","channels":["carrier-pigeon"]}`, http.StatusBadRequest, CodeValidationFailed, []string{"channels"}},
+		{"wrong type", "POST", "/complaints", `{"subject":42}`, http.StatusBadRequest, CodeInvalidBody, []string{"subject"}},
+		{"malformed json", "POST", "/complaints", `{`, http.StatusBadRequest, CodeInvalidBody, nil},
+		{"not found", "GET", "/complaints/missing", "", http.StatusNotFound, CodeNotFound, nil},
+		{"missing email fields", "POST", "/email/send", `{}`, http.StatusBadRequest, CodeValidationFailed, []string{"subject", "body"}},
+		{"unknown path", "GET", "/nowhere", "", http.StatusNotFound, CodeNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, problem := doProblemRequest(t, server, tt.method, tt.path, tt.body)
+
+			if problem.Status != tt.status || problem.Code != tt.code {
+				t.Errorf("got %d %s want %d %s", problem.Status, problem.Code, tt.status, tt.code)
+			}
+			if problem.Instance != tt.path {
+				t.Errorf("Instance: got %q want %q", problem.Instance, tt.path)
+			}
+			if len(problem.Errors) != len(tt.fields) {
+				t.Fatalf("Expected errors for %v, got %+v", tt.fields, problem.Errors)
+			}
+			for i, field := range tt.fields {
+				if problem.Errors[i].Field != field || problem.Errors[i].Code == "" || problem.Errors[i].Detail == "" {
+					t.Errorf("Error %d: got %+v want field %s", i, problem.Errors[i], field)
+				}
+			}
+		})
+	}
+}
+
+func TestProblemMethodNotAllowed(t *testing.T) {
+	server := newTestServer(t)
+
+	rr, problem := doProblemRequest(t, server, "DELETE", "/complaints", "")
+	if problem.Code != CodeMethodNotAllowed || rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Unexpected problem %+v", problem)
+	}
+	if allow := rr.Header().Get("Allow"); allow != "GET, POST" {
+		t.Errorf("Allow: got %q want %q", allow, "GET, POST")
+	}
+}
+
+func TestWriteEmailError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		status     int
+		code       ProblemCode
+		retryAfter string
+	}{
+		{"invalid recipient", &email.APIError{StatusCode: http.StatusBadRequest, Kind: email.ErrorInvalidRecipient, Message: "Invalid email address format."}, http.StatusUnprocessableEntity, CodeEmailRejected, ""},
+		{"throttled", fmt.Errorf("send: %w", &email.APIError{StatusCode: http.StatusTooManyRequests, Kind: email.ErrorThrottled, RetryAfter: 1500 * time.Millisecond}), http.StatusServiceUnavailable, CodeEmailThrottled, "2"},
+		{"auth", &email.APIError{StatusCode: http.StatusUnauthorized, Kind: email.ErrorAuth}, http.StatusBadGateway, CodeEmailUnavailable, ""},
+		{"transient", &email.APIError{StatusCode: http.StatusServiceUnavailable, Kind: email.ErrorTransient}, http.StatusBadGateway, CodeEmailUnavailable, ""},
+		{"other", errors.New("invalid email message: subject is required"), http.StatusInternalServerError, CodeEmailFailed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/email/send", nil)
+			rr := httptest.NewRecorder()
+			writeEmailError(rr, req, tt.err)
+
+			var problem Problem
+			if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if rr.Code != tt.status || problem.Code != tt.code {
+				t.Errorf("got %d %s want %d %s", rr.Code, problem.Code, tt.status, tt.code)
+			}
+			if got := rr.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("Retry-After: got %q want %q", got, tt.retryAfter)
+			}
+		})
+	}
+}
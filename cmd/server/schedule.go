@@ -0,0 +1,35 @@
+package main
+
+import (
+	"complaint-escalator/internal/cron"
+	"time"
+)
+
+// parseSchedule parses the cron schedule of a complaint in the time zone of the server,
+// or returns nil without an expression
+func (s *Server) parseSchedule(expr string) (*cron.Schedule, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return cron.Parse(expr, s.location)
+}
+
+// complaintSchedule returns the cron schedule of a complaint, or nil when it escalates
+// every interval. Complaints from the configuration have their own, all others use the
+// top-level schedule.
+func (s *Server) complaintSchedule(complaintID string) *cron.Schedule {
+	if schedule, ok := s.schedules[complaintID]; ok {
+		return schedule
+	}
+	return s.schedule
+}
+
+// firstSend returns when a complaint that starts escalating is sent first: at the next
+// time its schedule fires, or right away (the zero time) without a schedule
+func (s *Server) firstSend(complaintID string) time.Time {
+	schedule := s.complaintSchedule(complaintID)
+	if schedule == nil {
+		return time.Time{}
+	}
+	return schedule.Next(s.clock.Now())
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"complaint-escalator/internal/config"
+	"complaint-escalator/pkg/testutils"
+	"testing"
+	"time"
+)
+
+func TestNewServerWithSchedule(t *testing.T) {
+	cfg, err := config.LoadConfig(testutils.GetTestConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load test configuration: %v", err)
+	}
+	cfg.Schedule = "0 9 * * MON-FRI"
+	cfg.SendWindow.TimeZone = "Asia/Singapore"
+
+	// Friday 10am in Singapore, after the schedule fired
+	singapore, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Skipf("Time zone data not available: %v", err)
+	}
+	clock := testutils.NewFakeClock(time.Date(2025, 1, 3, 10, 0, 0, 0, singapore))
+
+	server, err := NewServerWithClock(cfg, clock)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.Stop()
+
+	// The schedule is matched in the time zone of the send window
+	schedule := server.complaintSchedule("default")
+	if schedule == nil || schedule.Location().String() != "Asia/Singapore" {
+		t.Fatalf("Expected a schedule in Asia/Singapore, got %v", schedule)
+	}
+
+	// The first send waits for the schedule, on Monday at 9am
+	monday := time.Date(2025, 1, 6, 9, 0, 0, 0, singapore)
+	if first := server.firstSend("default"); !first.Equal(monday) {
+		t.Errorf("Expected the first send at %v, got %v", monday, first)
+	}
+
+	// The schedule replaces the interval between rounds
+	clock.Advance(2 * 24 * time.Hour)
+	if interval := server.complaintInterval("default"); interval != 23*time.Hour {
+		t.Errorf("Expected an interval of 23h until Monday, got %v", interval)
+	}
+
+	// Complaints without a schedule are sent right away
+	cfg.Schedule = ""
+	plain, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer plain.Stop()
+	if at := plain.firstSend("default"); !at.IsZero() {
+		t.Errorf("Expected an immediate first send, got %v", at)
+	}
+	if interval := plain.complaintInterval("default"); interval != cfg.Interval {
+		t.Errorf("Expected the configured interval %v, got %v", cfg.Interval, interval)
+	}
+}
+
+func TestNewServerRejectsInvalidSchedule(t *testing.T) {
+	cfg, err := config.LoadConfig(testutils.GetTestConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load test configuration: %v", err)
+	}
+	cfg.Complaints = []config.ComplaintConfig{{ID: "refund", Template: "Please refund order #42.", Schedule: "0 9 31 2 *"}}
+
+	if _, err := NewServer(cfg); err == nil {
+		t.Error("Expected error for a schedule that never fires")
+	}
+}
@@ -1,19 +1,47 @@
 package main
 
 import (
+	"complaint-escalator/internal/ai"
+	"complaint-escalator/internal/complaint"
 	"complaint-escalator/internal/config"
+	"complaint-escalator/internal/cron"
 	"complaint-escalator/internal/email"
+	"complaint-escalator/internal/inbound"
+	"complaint-escalator/internal/notification"
+	"complaint-escalator/internal/scheduler"
+	"complaint-escalator/internal/storage"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
 	"log"
 	"net/http"
 	"time"
 )
 
+const (
+	// writeTimeout is how long a handler has to write its response
+	writeTimeout = 30 * time.Second
+	// emailSendTimeout bounds sending the email of POST /email/send, retries included,
+	// so the response is written before writeTimeout
+	emailSendTimeout = 15 * time.Second
+)
+
+// generateTimeout bounds generating the text of POST /email/send, which falls back to
+// the template when it runs out. Together with emailSendTimeout it stays within writeTimeout.
+var generateTimeout = 10 * time.Second
+
 // EmailRequest represents the JSON request structure for sending emails
 type EmailRequest struct {
 	Subject string `json:"subject"`
 	Body    string `json:"body"`
+	// Optional HTML body. When Body is empty, the plaintext is derived from it.
+	HTMLBody string `json:"html_body,omitempty"`
+	// Optional complaint whose recipients receive the email
+	ComplaintID string `json:"complaint_id,omitempty"`
+	// Optional files attached to the email
+	Attachments []AttachmentRequest `json:"attachments,omitempty"`
 }
 
 // EmailResponse represents the JSON response structure
@@ -23,38 +51,208 @@ type EmailResponse struct {
 	ID      string `json:"id,omitempty"`
 }
 
+// EmailStatusResponse represents the JSON response structure for the status of an email
+type EmailStatusResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	ID      string                `json:"id"`
+	Status  email.OperationStatus `json:"status,omitempty"`
+	Error   *email.OperationError `json:"error,omitempty"`
+}
+
 // Server represents the HTTP server
 type Server struct {
 	config      *config.Config
+	emailSender email.Sender
+	// emailClient is the ACS client, or nil when email is sent through SMTP
 	emailClient *email.EmailClient
-	httpServer  *http.Server
+	registry    *notification.Registry
+	dispatcher  *notification.Dispatcher
+	poller      *email.Poller
+	generator   ai.Generator
+	// htmlTemplate renders HTML email bodies, or is nil for plaintext only
+	htmlTemplate *template.Template
+	// tiers holds the escalation ladders of the complaints from the configuration
+	tiers map[string][]config.Tier
+	// schedule is the top-level cron schedule, or nil to escalate every interval, and
+	// schedules those of the complaints from the configuration
+	schedule  *cron.Schedule
+	schedules map[string]*cron.Schedule
+	// location is the time zone schedules are matched in
+	location *time.Location
+	// matcher matches inbound replies to complaints, or is nil without inbound configuration
+	matcher    *inbound.Matcher
+	complaints storage.Store
+	scheduler  *scheduler.Scheduler
+	// clock is the clock of the scheduler, which also times schedules and tiers
+	clock      scheduler.Clock
+	httpServer *http.Server
+}
+
+// newEmailSender creates the configured email transport. The ACS client is also
+// returned on its own because only ACS sends can be followed to completion.
+func newEmailSender(cfg config.Config) (email.Sender, *email.EmailClient, error) {
+	switch cfg.EmailTransport {
+	case "", "acs":
+		client, err := email.NewEmailClient(cfg.ACS.ConnectionString)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Transient ACS failures are retried right away, everything else is left to the scheduler
+		sender, err := email.NewRetrySender(client, email.DefaultRetryPolicy)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sender, client, nil
+	case "smtp":
+		client, err := email.NewSMTPClient(email.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Security: cfg.SMTP.Security,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			Auth:     cfg.SMTP.Auth,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("smtp: %w", err)
+		}
+		return client, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown email transport %q", cfg.EmailTransport)
+	}
 }
 
 // NewServer creates a new HTTP server instance
 func NewServer(cfg config.Config) (*Server, error) {
-	// Initialize email client
-	emailClient, err := email.NewEmailClient(cfg.ACS.ConnectionString)
+	return NewServerWithClock(cfg, scheduler.SystemClock{})
+}
+
+// NewServerWithClock creates a new HTTP server instance whose escalations are timed by
+// clock. A nil clock uses the wall clock.
+func NewServerWithClock(cfg config.Config, clock scheduler.Clock) (*Server, error) {
+	if clock == nil {
+		clock = scheduler.SystemClock{}
+	}
+
+	// Initialize email transport
+	emailSender, emailClient, err := newEmailSender(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize email client: %w", err)
 	}
 
+	// Initialize AI text generation
+	generator, err := ai.NewGenerator(cfg.AI.Endpoint, cfg.AI.Model, cfg.AI.APIKey, cfg.AI.MaxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize AI generator: %w", err)
+	}
+
+	// Initialize notification channels
+	registry, err := newRegistry(cfg, emailSender)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize notification channels: %w", err)
+	}
+
+	// Initialize HTML email template
+	htmlTemplate, err := parseHTMLTemplate(cfg.HTMLTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML template: %w", err)
+	}
+
+	// Initialize inbound reply matching
+	matcher, err := newMatcher(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inbound replies: %w", err)
+	}
+
+	// Initialize send window
+	sendWindow, err := newSendWindow(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize send window: %w", err)
+	}
+
+	// Initialize storage
+	var store storage.Store = storage.NewMemoryStore()
+	if cfg.Storage.Path != "" {
+		store, err = storage.NewBoltStore(cfg.Storage.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize storage: %w", err)
+		}
+	}
+
 	server := &Server{
-		config:      &cfg,
-		emailClient: emailClient,
+		config:       &cfg,
+		emailSender:  emailSender,
+		emailClient:  emailClient,
+		registry:     registry,
+		dispatcher:   notification.NewDispatcher(registry),
+		generator:    generator,
+		htmlTemplate: htmlTemplate,
+		tiers:        make(map[string][]config.Tier),
+		schedules:    make(map[string]*cron.Schedule),
+		location:     time.Local,
+		matcher:      matcher,
+		complaints:   store,
+		clock:        clock,
+	}
+
+	// Schedules are matched in the time zone of the recipients when it is known
+	if sendWindow != nil {
+		server.location = sendWindow.Location()
+	}
+	server.schedule, err = server.parseSchedule(cfg.Schedule)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to parse schedule: %w", err)
+	}
+
+	// Only ACS sends are long-running operations that can be followed
+	if emailClient != nil {
+		server.poller = email.NewPoller(emailClient, 5*time.Second, time.Hour)
+	}
+
+	// Initialize escalation scheduler
+	server.scheduler, err = scheduler.NewSchedulerWithPolicies(cfg.CadencePolicy(), cfg.Backoff, server.escalate, clock)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to initialize scheduler: %w", err)
+	}
+	server.scheduler.OnSchedule(server.recordNextSend)
+	server.scheduler.OnInterval(server.complaintInterval)
+	if sendWindow != nil {
+		server.scheduler.OnWindow(sendWindow.Next)
+	}
+
+	// Add the complaints from the configuration and pick up the stored schedule
+	if err := server.seedComplaints(cfg); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to load complaints: %w", err)
+	}
+	if err := server.restoreSchedule(); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to restore schedule: %w", err)
 	}
 
 	// Create HTTP server
 	mux := http.NewServeMux()
 
 	// Register routes
+	mux.HandleFunc("/", server.notFoundHandler)
 	mux.HandleFunc("/health", server.healthHandler)
 	mux.HandleFunc("/email/send", server.sendEmailHandler)
+	mux.HandleFunc("/email/{id}/status", server.emailStatusHandler)
+	mux.HandleFunc("/complaints", server.complaintsHandler)
+	mux.HandleFunc("/complaints/{id}", server.complaintHandler)
+	mux.HandleFunc("/complaints/{id}/pause", server.transitionHandler(complaint.StatePaused))
+	mux.HandleFunc("/complaints/{id}/resume", server.transitionHandler(complaint.StateEscalating))
+	mux.HandleFunc("/complaints/{id}/resolve", server.transitionHandler(complaint.StateResolved))
+	mux.HandleFunc("/complaints/{id}/attempts", server.attemptsHandler)
+	mux.HandleFunc("/inbound/email", server.inboundEmailHandler)
 
 	server.httpServer = &http.Server{
 		Addr:         ":8080",
 		Handler:      mux,
 		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		WriteTimeout: writeTimeout,
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -67,20 +265,278 @@ func (s *Server) Start() error {
 	log.Printf("Available endpoints:")
 	log.Printf("  GET  /health")
 	log.Printf("  POST /email/send")
+	log.Printf("  GET  /email/{id}/status")
+	log.Printf("  GET  /complaints")
+	log.Printf("  POST /complaints")
+	log.Printf("  GET  /complaints/{id}")
+	log.Printf("  PUT  /complaints/{id}")
+	log.Printf("  POST /complaints/{id}/pause")
+	log.Printf("  POST /complaints/{id}/resume")
+	log.Printf("  POST /complaints/{id}/resolve")
+	log.Printf("  GET  /complaints/{id}/attempts")
+	log.Printf("  POST /inbound/email")
 
 	return s.httpServer.ListenAndServe()
 }
 
-// Stop gracefully stops the HTTP server
+// StartScheduler starts the escalation scheduler in the background
+func (s *Server) StartScheduler(ctx context.Context) {
+	if s.schedule != nil {
+		log.Printf("Starting escalation scheduler (schedule %q, backoff %v)", s.schedule, s.config.Backoff)
+	} else {
+		log.Printf("Starting escalation scheduler (interval %v, backoff %v)", s.config.CadencePolicy(), s.config.Backoff)
+	}
+	s.scheduler.Start(ctx)
+}
+
+// Stop gracefully stops the HTTP server, the escalation scheduler and the storage
 func (s *Server) Stop() error {
 	log.Println("Shutting down HTTP server...")
-	return s.httpServer.Close()
+	err := s.httpServer.Close()
+	if s.scheduler != nil {
+		s.scheduler.Stop()
+	}
+	if s.poller != nil {
+		s.poller.Stop()
+	}
+	if s.complaints != nil {
+		if closeErr := s.complaints.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// seedComplaints adds the complaints from the configuration that are not stored yet.
+// New complaints start escalating right away.
+func (s *Server) seedComplaints(cfg config.Config) error {
+	if err := s.validateTiers(cfg.Tiers); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, cc := range cfg.ComplaintConfigs() {
+		recipients := complaint.Recipients{
+			To:      cc.Email.To,
+			CC:      cc.Email.CC,
+			BCC:     cc.Email.BCC,
+			ReplyTo: cc.Email.ReplyTo,
+		}
+		c, err := complaint.New(cc.ID, cc.Subject, cc.Template, recipients, cc.Channels, now)
+		if err != nil {
+			return fmt.Errorf("complaint %q: %w", cc.ID, err)
+		}
+		if err := s.validateChannels(c.Channels); err != nil {
+			return fmt.Errorf("complaint %q: %w", cc.ID, err)
+		}
+		if err := s.validateTiers(cc.Tiers); err != nil {
+			return fmt.Errorf("complaint %q: %w", cc.ID, err)
+		}
+		s.tiers[cc.ID] = cc.Tiers
+		schedule, err := s.parseSchedule(cc.Schedule)
+		if err != nil {
+			return fmt.Errorf("complaint %q: schedule: %w", cc.ID, err)
+		}
+		s.schedules[cc.ID] = schedule
+		if err := c.Transition(complaint.StateEscalating, now); err != nil {
+			return fmt.Errorf("complaint %q: %w", cc.ID, err)
+		}
+		if err := s.complaints.AddComplaint(c); err != nil {
+			if errors.Is(err, complaint.ErrAlreadyExists) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreSchedule schedules every escalating complaint at its stored next send time
+func (s *Server) restoreSchedule() error {
+	complaints, err := s.complaints.ListComplaints()
+	if err != nil {
+		return err
+	}
+	for _, c := range complaints {
+		if c.State != complaint.StateEscalating {
+			continue
+		}
+		at := s.firstSend(c.ID)
+		if c.NextSendAt != nil {
+			at = *c.NextSendAt
+		}
+		s.scheduler.Schedule(c.ID, at)
+	}
+	return nil
+}
+
+// recordNextSend persists the planned time of the next send of a complaint
+func (s *Server) recordNextSend(complaintID string, at time.Time) {
+	_, err := s.complaints.UpdateComplaint(complaintID, func(c *complaint.Complaint) error {
+		if c.State != complaint.StateEscalating {
+			return nil
+		}
+		c.NextSendAt = &at
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to store next send of complaint %s: %v", complaintID, err)
+	}
+}
+
+// recordAttempt persists a send attempt in the history
+func (s *Server) recordAttempt(complaintID string, number int, channel, operationID string, sendErr error) {
+	attempt := storage.Attempt{
+		ComplaintID: complaintID,
+		Number:      number,
+		Channel:     channel,
+		SentAt:      time.Now(),
+		Success:     sendErr == nil,
+		OperationID: operationID,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := s.complaints.RecordAttempt(attempt); err != nil {
+		log.Printf("Failed to record attempt of complaint %s: %v", complaintID, err)
+	}
+}
+
+// escalate sends the complaint through every channel of its current tier
+func (s *Server) escalate(ctx context.Context, complaintID string) error {
+	c, err := s.complaints.GetComplaint(complaintID)
+	if err != nil {
+		return err
+	}
+	if c.State != complaint.StateEscalating {
+		log.Printf("Skipping complaint %s in state %s", c.ID, c.State)
+		return nil
+	}
+
+	// Move up the escalation ladder first, so the round goes to the new tier
+	if c, err = s.advanceTier(c); err != nil {
+		return err
+	}
+	c, tone := s.withTier(c)
+
+	number := c.Attempts + 1
+	text := s.generateText(ctx, c, number, tone)
+	html := s.renderHTML(c, c.Subject, text, number)
+
+	msg := notification.Message{
+		ComplaintID: c.ID,
+		Attempt:     number,
+		Subject:     c.Subject,
+		Text:        text,
+		HTML:        html,
+		To:          c.Recipients.To,
+		CC:          c.Recipients.CC,
+		BCC:         c.Recipients.BCC,
+		ReplyTo:     s.replyTo(c),
+		ThreadID:    s.threadID(c),
+	}
+
+	results := s.dispatcher.Dispatch(ctx, c.Channels, msg)
+	for _, result := range results {
+		s.recordAttempt(c.ID, number, result.Channel, result.Receipt.ID, result.Err)
+		if result.Err != nil {
+			continue
+		}
+		if result.Channel == "email" {
+			s.trackOperation(result.Receipt.ID)
+		}
+	}
+
+	// The round only counts when at least one channel delivered it to a recipient. The
+	// service log and the webhook reports do not.
+	if !results.Succeeded() {
+		return roundError(results)
+	}
+	if err := results.Err(); err != nil {
+		log.Printf("Complaint %s was not delivered on every channel: %v", c.ID, err)
+	}
+
+	_, err = s.complaints.UpdateComplaint(c.ID, func(c *complaint.Complaint) error {
+		c.RecordSend(s.clock.Now())
+		return nil
+	})
+	return err
+}
+
+// roundError tells the scheduler how to handle a round that no channel delivered.
+// It gives up the round when every channel was rejected for good by the email service,
+// and otherwise retries no sooner than any channel was asked to wait.
+func roundError(results notification.Results) error {
+	err := fmt.Errorf("no channel delivered the round: %w", results.Err())
+
+	permanent := true
+	var after time.Duration
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		var apiErr *email.APIError
+		if !errors.As(result.Err, &apiErr) || apiErr.Temporary() {
+			permanent = false
+		}
+		if wait, ok := email.Retryable(result.Err); ok {
+			after = max(after, wait)
+		}
+		var rateLimit *notification.RateLimitError
+		if errors.As(result.Err, &rateLimit) {
+			after = max(after, rateLimit.RetryAfter)
+		}
+	}
+
+	switch {
+	case permanent:
+		return scheduler.GiveUp(err)
+	case after > 0:
+		return scheduler.TryLater(err, after)
+	default:
+		return err
+	}
+}
+
+// generateText generates a fresh wording of the complaint for the given escalation round
+func (s *Server) generateText(ctx context.Context, c complaint.Complaint, round int, tone string) string {
+	generator := s.generator
+	if generator == nil {
+		generator = ai.Passthrough{}
+	}
+	return ai.GenerateAIText(ctx, generator, ai.Prompt{Template: c.Template, Round: round, Tone: tone})
+}
+
+// complaintEmailMessage creates an email message addressed to the recipients of a complaint
+func (s *Server) complaintEmailMessage(c complaint.Complaint, subject, body string) email.EmailMessage {
+	msg := email.CreateEmailMessageFromConfig(
+		s.config.FromEmail(),
+		c.Recipients.To,
+		c.Recipients.CC,
+		c.Recipients.BCC,
+		s.replyTo(c),
+		subject,
+		body,
+	)
+
+	// One-off emails join the thread once escalation has started it
+	if c.Attempts > 0 {
+		msg.InReplyTo = s.threadID(c)
+		msg.References = []string{msg.InReplyTo}
+	}
+	return msg
+}
+
+// threadID returns the Message-ID rooting the email thread of a complaint. The creation
+// time keeps a complaint that reuses the id of a deleted one out of the old thread.
+func (s *Server) threadID(c complaint.Complaint) string {
+	return email.ThreadRootID(inbound.ThreadKey(c.ID, c.CreatedAt), s.config.FromEmail())
 }
 
 // healthHandler handles health check requests
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r, http.MethodGet)
 		return
 	}
 
@@ -99,63 +555,149 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 // sendEmailHandler handles email sending requests
 func (s *Server) sendEmailHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r, http.MethodPost)
 		return
 	}
 
 	// Parse request body
-	var emailReq EmailRequest
-	if err := json.NewDecoder(r.Body).Decode(&emailReq); err != nil {
-		http.Error(w, "Invalid JSON request body", http.StatusBadRequest)
+	emailReq, err := decodeEmailRequest(w, r)
+	if err != nil {
+		writeInvalidBody(w, r, err.Error(), err)
 		return
 	}
+
+	ctx := r.Context()
+
+	// Resolve the complaint, whose subject and template fill in a missing subject and body
+	var target *complaint.Complaint
+	if emailReq.ComplaintID != "" {
+		c, err := s.complaints.GetComplaint(emailReq.ComplaintID)
+		if err != nil {
+			writeComplaintError(w, r, complaintErrorStatus(err), err)
+			return
+		}
+		c, tone := s.withTier(c)
+		target = &c
+		if emailReq.Subject == "" {
+			emailReq.Subject = c.Subject
+		}
+		if emailReq.Body == "" && emailReq.HTMLBody == "" {
+			genCtx, cancel := context.WithTimeout(ctx, generateTimeout)
+			emailReq.Body = s.generateText(genCtx, c, c.Attempts+1, tone)
+			cancel()
+			emailReq.HTMLBody = s.renderHTML(c, emailReq.Subject, emailReq.Body, c.Attempts+1)
+		}
+	}
+
+	var invalid []FieldProblem
 	if emailReq.Subject == "" {
-		http.Error(w, "Subject is required", http.StatusBadRequest)
-		return
+		invalid = append(invalid, FieldProblem{Field: "subject", Code: "required", Detail: "Subject is required"})
+	}
+	if emailReq.Body == "" && emailReq.HTMLBody == "" {
+		invalid = append(invalid, FieldProblem{Field: "body", Code: "required", Detail: "Body is required"})
 	}
-	if emailReq.Body == "" {
-		http.Error(w, "Body is required", http.StatusBadRequest)
+	if len(invalid) > 0 {
+		writeValidationError(w, r, invalid...)
 		return
 	}
 
-	emailMsg := email.CreateEmailMessageFromConfig(
-		s.config.ACS.FromEmail,
-		s.config.Email.To,
-		s.config.Email.CC,
-		s.config.Email.BCC,
-		s.config.Email.ReplyTo,
-		emailReq.Subject,
-		emailReq.Body,
-	)
+	var emailMsg email.EmailMessage
+	if target != nil {
+		emailMsg = s.complaintEmailMessage(*target, emailReq.Subject, emailReq.Body)
+	} else {
+		emailMsg = email.CreateEmailMessageFromConfig(
+			s.config.FromEmail(),
+			s.config.Email.To,
+			s.config.Email.CC,
+			s.config.Email.BCC,
+			s.config.Email.ReplyTo,
+			emailReq.Subject,
+			emailReq.Body,
+		)
+	}
+	emailMsg.HTMLBody = emailReq.HTMLBody
+	emailMsg.Attachments = emailAttachments(emailReq.Attachments)
 
 	// Send email
-	ctx := r.Context()
-	err := s.emailClient.SendEmail(ctx, emailMsg)
-
-	w.Header().Set("Content-Type", "application/json")
+	sendCtx, cancel := context.WithTimeout(ctx, emailSendTimeout)
+	defer cancel()
+	operationID, err := s.emailSender.SendEmail(sendCtx, emailMsg)
+	if s.complaints != nil {
+		s.recordAttempt(emailReq.ComplaintID, 0, "email", operationID, err)
+	}
 
 	if err != nil {
 		log.Printf("Failed to send email: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-
-		response := EmailResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to send email: %v", err),
-		}
-		json.NewEncoder(w).Encode(response)
+		writeEmailError(w, r, err)
 		return
 	}
 
+	s.trackOperation(operationID)
+
 	// Success response
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	response := EmailResponse{
 		Success: true,
 		Message: "Email sent successfully",
-		ID:      fmt.Sprintf("email_%d", time.Now().Unix()),
+		ID:      operationID,
 	}
 	json.NewEncoder(w).Encode(response)
 }
 
+// trackOperation follows an email send operation until it succeeds or fails
+func (s *Server) trackOperation(operationID string) {
+	if s.poller != nil {
+		s.poller.Track(operationID)
+	}
+}
+
+// emailStatusHandler returns the status of an email send operation
+func (s *Server) emailStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	if s.emailClient == nil {
+		writeError(w, r, http.StatusNotImplemented, CodeNotImplemented, "Email status is only available with the ACS email transport")
+		return
+	}
+
+	// Prefer the tracked status and fall back to asking ACS directly
+	var op email.Operation
+	ok := false
+	if s.poller != nil {
+		op, ok = s.poller.Status(id)
+	}
+	if !ok {
+		var err error
+		op, _, err = s.emailClient.GetOperation(r.Context(), id)
+		if err != nil {
+			detail := fmt.Sprintf("Failed to get email status: %v", err)
+			if errors.Is(err, email.ErrOperationNotFound) {
+				writeError(w, r, http.StatusNotFound, CodeNotFound, detail)
+				return
+			}
+			writeError(w, r, http.StatusBadGateway, CodeEmailUnavailable, detail)
+			return
+		}
+	}
+
+	response := EmailStatusResponse{
+		Success: op.Status != email.OperationFailed && op.Status != email.OperationCanceled,
+		Message: fmt.Sprintf("Email operation is %s", op.Status),
+		ID:      id,
+		Status:  op.Status,
+		Error:   op.Error,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
 // middleware for logging and CORS
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
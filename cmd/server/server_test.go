@@ -2,13 +2,22 @@ package main
 
 import (
 	"bytes"
+	"complaint-escalator/internal/ai"
+	"complaint-escalator/internal/complaint"
 	"complaint-escalator/internal/config"
 	"complaint-escalator/internal/email"
+	"complaint-escalator/internal/notification"
+	"complaint-escalator/internal/scheduler"
 	"complaint-escalator/pkg/testutils"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestHealthHandler(t *testing.T) {
@@ -135,6 +144,7 @@ func TestSendEmailHandler_MissingRecipients(t *testing.T) {
 
 	server := &Server{
 		config:      &cfg,
+		emailSender: emailClient,
 		emailClient: emailClient,
 	}
 
@@ -211,3 +221,460 @@ func TestSendEmailHandler_ValidRequest(t *testing.T) {
 		t.Logf("Expected internal server error due to test credentials, got status: %d", rr.Code)
 	}
 }
+
+func TestSendEmailHandler_UnknownComplaint(t *testing.T) {
+	cfg, err := config.LoadConfig(testutils.GetTestConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load test configuration: %v", err)
+	}
+
+	server, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	jsonData, err := json.Marshal(EmailRequest{ComplaintID: "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", "/email/send", bytes.NewBuffer(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(server.sendEmailHandler)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+}
+
+func TestNewServerSeedsComplaints(t *testing.T) {
+	cfg, err := config.LoadConfig(testutils.GetTestConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load test configuration: %v", err)
+	}
+
+	server, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	c, err := server.complaints.GetComplaint("default")
+	if err != nil {
+		t.Fatalf("Default complaint not created: %v", err)
+	}
+	if c.State != complaint.StateEscalating {
+		t.Errorf("Expected state %s, got %s", complaint.StateEscalating, c.State)
+	}
+	if !server.scheduler.Scheduled(c.ID) {
+		t.Error("Default complaint should be scheduled")
+	}
+}
+
+func TestNewServerRestoresStoredSchedule(t *testing.T) {
+	cfg, err := config.LoadConfig(testutils.GetTestConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load test configuration: %v", err)
+	}
+	cfg.Storage.Path = filepath.Join(t.TempDir(), "complaints.db")
+
+	// Store a planned send and an attempt
+	server, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	next := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	server.recordNextSend("default", next)
+	server.recordAttempt("default", 1, "email", "op-1", nil)
+	if err := server.Stop(); err != nil {
+		t.Fatalf("Failed to stop server: %v", err)
+	}
+
+	// A new server picks up the stored complaint instead of seeding a fresh one
+	server, err = NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to recreate server: %v", err)
+	}
+	defer server.Stop()
+
+	c, err := server.complaints.GetComplaint("default")
+	if err != nil {
+		t.Fatalf("Default complaint not restored: %v", err)
+	}
+	if c.NextSendAt == nil || !c.NextSendAt.Equal(next) {
+		t.Errorf("Expected next send at %v, got %v", next, c.NextSendAt)
+	}
+	if !server.scheduler.Scheduled("default") {
+		t.Error("Restored complaint should be scheduled")
+	}
+	attempts, err := server.complaints.ListAttempts("default")
+	if err != nil || len(attempts) != 1 {
+		t.Errorf("Attempts not restored: %v, %v", attempts, err)
+	}
+}
+
+func TestEmailStatusHandler(t *testing.T) {
+	// Fake ACS operations endpoint
+	acs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/emails/operations/op-live" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(email.Operation{ID: "op-live", Status: email.OperationSucceeded})
+	}))
+	defer acs.Close()
+
+	emailClient, err := email.NewEmailClient("endpoint=" + acs.URL + "/;accesskey=dGVzdC1hY2Nlc3Mta2V5")
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+
+	server := &Server{
+		emailClient: emailClient,
+		poller:      email.NewPoller(emailClient, time.Hour, time.Hour),
+	}
+	defer server.poller.Stop()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/email/{id}/status", server.emailStatusHandler)
+
+	tests := []struct {
+		id     string
+		status int
+		want   email.OperationStatus
+	}{
+		// Tracked operations report the last polled status
+		{"op-tracked", http.StatusOK, email.OperationRunning},
+		// Untracked operations are looked up in ACS
+		{"op-live", http.StatusOK, email.OperationSucceeded},
+		{"op-missing", http.StatusNotFound, ""},
+	}
+	server.poller.Track("op-tracked")
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", "/email/"+tt.id+"/status", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+
+		if rr.Code != tt.status {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", tt.id, rr.Code, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			var problem Problem
+			if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Code != CodeNotFound || problem.Instance != "/email/"+tt.id+"/status" {
+				t.Errorf("%s: unexpected problem %+v", tt.id, problem)
+			}
+			continue
+		}
+		var response EmailStatusResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Status != tt.want || response.ID != tt.id {
+			t.Errorf("%s: unexpected response %+v", tt.id, response)
+		}
+	}
+}
+
+// fakeNotifier is a notifier that fails with err, or succeeds when err is nil
+type fakeNotifier struct {
+	err error
+}
+
+func (n fakeNotifier) Send(ctx context.Context, msg notification.Message) (notification.Receipt, error) {
+	if n.err != nil {
+		return notification.Receipt{}, n.err
+	}
+	return notification.Receipt{ID: "receipt-1", SentAt: time.Now()}, nil
+}
+
+func TestEscalateDispatchesToChannels(t *testing.T) {
+	cfg, err := config.LoadConfig(testutils.GetTestConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load test configuration: %v", err)
+	}
+
+	server, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server.poller = nil
+
+	// One channel failing does not fail the round
+	server.registry.Register("email", fakeNotifier{err: errors.New("mailbox full")})
+	server.registry.Register("notification", fakeNotifier{})
+	if err := server.escalate(context.Background(), "default"); err != nil {
+		t.Fatalf("Expected partial delivery to succeed: %v", err)
+	}
+
+	c, err := server.complaints.GetComplaint("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", c.Attempts)
+	}
+	attempts, err := server.complaints.ListAttempts("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 2 || attempts[0].Success || !attempts[1].Success || attempts[1].OperationID != "receipt-1" {
+		t.Errorf("Unexpected attempts: %+v", attempts)
+	}
+
+	// Every channel failing fails the round
+	server.registry.Register("notification", fakeNotifier{err: errors.New("unavailable")})
+	if err := server.escalate(context.Background(), "default"); err == nil {
+		t.Error("Expected error when every channel failed")
+	}
+	c, err = server.complaints.GetComplaint("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Attempts != 1 {
+		t.Errorf("Failed round should not count, got %d attempts", c.Attempts)
+	}
+}
+
+func TestEscalateRetriesRoundOnlyLogged(t *testing.T) {
+	server := newTestServer(t)
+	server.poller = nil
+
+	// The service log succeeding does not deliver the round
+	unavailable := &email.APIError{StatusCode: http.StatusServiceUnavailable, Kind: email.ErrorTransient}
+	server.registry.Register("email", fakeNotifier{err: unavailable})
+	err := server.escalate(context.Background(), "default")
+	if err == nil {
+		t.Fatal("Expected the round to fail when only the log channel succeeded")
+	}
+	var giveUp *scheduler.GiveUpError
+	if errors.As(err, &giveUp) {
+		t.Errorf("Expected the round to be retried, got %v", err)
+	}
+
+	c, err := server.complaints.GetComplaint("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Attempts != 0 || c.LastSentAt != nil {
+		t.Errorf("Failed round should not count, got %d attempts sent at %v", c.Attempts, c.LastSentAt)
+	}
+
+	// The retry is counted once email goes through
+	server.registry.Register("email", fakeNotifier{})
+	if err := server.escalate(context.Background(), "default"); err != nil {
+		t.Fatalf("Failed to retry the round: %v", err)
+	}
+	if c, err = server.complaints.GetComplaint("default"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", c.Attempts)
+	}
+}
+
+func TestNewServerRegistersConfiguredChannels(t *testing.T) {
+	cfg, err := config.LoadConfig(testutils.GetTestConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load test configuration: %v", err)
+	}
+
+	server, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if _, ok := server.registry.Get("slack"); ok {
+		t.Error("slack should not be available without a webhook URL")
+	}
+
+	cfg.Slack.WebhookURL = "https://hooks.slack.com/services/T000/B000/XXXX"
+	cfg.Teams.WebhookURL = "https://example.webhook.office.com/webhookb2/xyz"
+	cfg.Discord.WebhookURL = "https://discord.com/api/webhooks/1/token"
+	server, err = NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	for _, channel := range []string{"slack", "teams", "discord"} {
+		if _, ok := server.registry.Get(channel); !ok {
+			t.Errorf("%s should be available with a webhook URL", channel)
+		}
+	}
+
+	// Incomplete channel settings are rejected
+	cfg.Telegram.BotToken = "123:abc"
+	if _, err := NewServer(cfg); err == nil {
+		t.Error("Expected error for telegram without chat ids")
+	}
+	cfg.Telegram.ChatIDs = []string{"42"}
+	server, err = NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if _, ok := server.registry.Get("telegram"); !ok {
+		t.Error("telegram should be available with a bot token")
+	}
+}
+
+func TestNewServerWithSMTPTransport(t *testing.T) {
+	cfg, err := config.LoadConfig(testutils.GetTestConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load test configuration: %v", err)
+	}
+	cfg.EmailTransport = "smtp"
+	cfg.SMTP.Host = "smtp.example.com"
+	cfg.SMTP.FromEmail = "escalator@example.com"
+
+	server, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if _, ok := server.emailSender.(*email.SMTPClient); !ok {
+		t.Errorf("Expected SMTP email sender, got %T", server.emailSender)
+	}
+	if server.poller != nil {
+		t.Error("SMTP sends should not be polled")
+	}
+	if from := server.config.FromEmail(); from != "escalator@example.com" {
+		t.Errorf("Expected SMTP sender address, got %s", from)
+	}
+
+	// Operation status is an ACS feature
+	req, err := http.NewRequest("GET", "/email/op-1/status", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	server.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("status returned wrong status code: got %v want %v", rr.Code, http.StatusNotImplemented)
+	}
+
+	cfg.EmailTransport = "carrier-pigeon"
+	if _, err := NewServer(cfg); err == nil {
+		t.Error("Expected error for unknown email transport")
+	}
+}
+
+func TestEscalateThreadsEmails(t *testing.T) {
+	server := newTestServer(t)
+
+	sender := &recordingSender{}
+	notifier, err := notification.NewEmailNotifier(sender, server.config.FromEmail(), email.SubjectRe)
+	if err != nil {
+		t.Fatalf("Failed to create email notifier: %v", err)
+	}
+	server.registry.Register("email", notifier)
+	server.registry.Register("notification", &recordingNotifier{})
+	server.poller = nil
+
+	for i := 0; i < 2; i++ {
+		if err := server.escalate(context.Background(), "default"); err != nil {
+			t.Fatalf("Failed to escalate: %v", err)
+		}
+	}
+
+	if len(sender.messages) != 2 {
+		t.Fatalf("Expected 2 emails, got %d", len(sender.messages))
+	}
+	first, second := sender.messages[0], sender.messages[1]
+	if !strings.HasPrefix(first.MessageID, "<complaint.default.") {
+		t.Errorf("Expected the first email to start the thread, got Message-ID %q", first.MessageID)
+	}
+	if second.InReplyTo != first.MessageID || len(second.References) != 1 || second.References[0] != first.MessageID {
+		t.Errorf("Expected the second email to reply to %s, got %+v", first.MessageID, second)
+	}
+	if second.Subject != "Re: "+first.Subject {
+		t.Errorf("Expected subject Re: %s, got %s", first.Subject, second.Subject)
+	}
+
+	// One-off emails for the complaint join the thread
+	c, err := server.complaints.GetComplaint("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := server.complaintEmailMessage(c, "Subject", "Body"); msg.InReplyTo != first.MessageID {
+		t.Errorf("Expected one-off email to reply to %s, got %q", first.MessageID, msg.InReplyTo)
+	}
+}
+
+func TestRoundError(t *testing.T) {
+	invalidRecipient := &email.APIError{StatusCode: http.StatusBadRequest, Kind: email.ErrorInvalidRecipient}
+	throttled := &email.APIError{StatusCode: http.StatusTooManyRequests, Kind: email.ErrorThrottled, RetryAfter: 2 * time.Minute}
+
+	// Every channel rejected for good gives up the round
+	err := roundError(notification.Results{{Channel: "email", Err: invalidRecipient}})
+	var giveUp *scheduler.GiveUpError
+	if !errors.As(err, &giveUp) || !errors.Is(err, email.ErrInvalidRecipient) {
+		t.Errorf("Expected the round to be given up, got %v", err)
+	}
+
+	// A throttled channel is retried no sooner than it asked
+	err = roundError(notification.Results{
+		{Channel: "email", Err: throttled},
+		{Channel: "slack", Err: &notification.RateLimitError{RetryAfter: time.Minute}},
+	})
+	var later *scheduler.TryLaterError
+	if !errors.As(err, &later) || later.After != 2*time.Minute {
+		t.Errorf("Expected a retry after 2m, got %v", err)
+	}
+
+	// Other failures keep the regular retries, even next to a permanent one
+	err = roundError(notification.Results{
+		{Channel: "email", Err: invalidRecipient},
+		{Channel: "slack", Err: errors.New("slack returned status 500")},
+	})
+	if errors.As(err, &giveUp) || errors.As(err, &later) {
+		t.Errorf("Expected a plain error, got %T", err)
+	}
+}
+
+// blockingGenerator is a generator that does not answer until the context is done
+type blockingGenerator struct{}
+
+func (blockingGenerator) Generate(ctx context.Context, prompt ai.Prompt) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestSendEmailHandler_GenerationDeadline(t *testing.T) {
+	server := newTestServer(t)
+	sender := &recordingSender{}
+	server.emailSender = sender
+	server.generator = blockingGenerator{}
+
+	previous := generateTimeout
+	generateTimeout = 10 * time.Millisecond
+	defer func() { generateTimeout = previous }()
+
+	c, err := server.complaints.GetComplaint("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := json.Marshal(EmailRequest{ComplaintID: c.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/email/send", bytes.NewReader(jsonData))
+	rr := httptest.NewRecorder()
+	server.sendEmailHandler(rr, req)
+
+	// A generator that does not answer in time gives way to the template
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if len(sender.messages) != 1 || sender.messages[0].Body != c.Template {
+		t.Errorf("Expected the template as body, got %+v", sender.messages)
+	}
+}
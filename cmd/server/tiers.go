@@ -0,0 +1,119 @@
+package main
+
+import (
+	"complaint-escalator/internal/complaint"
+	"complaint-escalator/internal/config"
+	"fmt"
+	"log"
+	"time"
+)
+
+// validateTiers validates an escalation ladder. Every tier but the last needs a limit,
+// or complaints would never move past it.
+func (s *Server) validateTiers(tiers []config.Tier) error {
+	for i, tier := range tiers {
+		name := tier.Name
+		if name == "" {
+			name = fmt.Sprint(i + 1)
+		}
+		if tier.Attempts < 0 || tier.After < 0 || tier.Interval < 0 {
+			return fmt.Errorf("tier %s: attempts, after and interval cannot be negative", name)
+		}
+		if i < len(tiers)-1 && tier.Attempts == 0 && tier.After == 0 {
+			return fmt.Errorf("tier %s: attempts or after is required to move up to the next tier", name)
+		}
+		if err := s.validateChannels(tier.Channels); err != nil {
+			return fmt.Errorf("tier %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ladder returns the escalation tiers of a complaint. Complaints from the configuration
+// have their own, all others use the top-level tiers.
+func (s *Server) ladder(complaintID string) []config.Tier {
+	if tiers, ok := s.tiers[complaintID]; ok {
+		return tiers
+	}
+	return s.config.Tiers
+}
+
+// currentTier returns the tier a complaint is in, if it has an escalation ladder
+func (s *Server) currentTier(c complaint.Complaint) (config.Tier, bool) {
+	tiers := s.ladder(c.ID)
+	if len(tiers) == 0 {
+		return config.Tier{}, false
+	}
+	return tiers[min(c.Tier, len(tiers)-1)], true
+}
+
+// advanceTier moves the complaint up its ladder when its current tier has reached its limit
+func (s *Server) advanceTier(c complaint.Complaint) (complaint.Complaint, error) {
+	tiers := s.ladder(c.ID)
+	if len(tiers) == 0 {
+		return c, nil
+	}
+
+	limits := make([]complaint.TierLimit, len(tiers))
+	for i, tier := range tiers {
+		limits[i] = complaint.TierLimit{Attempts: tier.Attempts, After: tier.After}
+	}
+
+	moved := false
+	c, err := s.complaints.UpdateComplaint(c.ID, func(c *complaint.Complaint) error {
+		moved = c.AdvanceTier(limits, s.clock.Now())
+		return nil
+	})
+	if err != nil {
+		return c, err
+	}
+	if moved {
+		log.Printf("Complaint %s moved up to tier %s", c.ID, tiers[min(c.Tier, len(tiers)-1)].Name)
+	}
+	return c, nil
+}
+
+// withTier returns the complaint with the recipients, channels and template of its
+// current tier, and the tone the tier asks for
+func (s *Server) withTier(c complaint.Complaint) (complaint.Complaint, string) {
+	tier, ok := s.currentTier(c)
+	if !ok {
+		return c, ""
+	}
+	if len(tier.Email.To) > 0 {
+		c.Recipients = complaint.Recipients{
+			To:      tier.Email.To,
+			CC:      tier.Email.CC,
+			BCC:     tier.Email.BCC,
+			ReplyTo: tier.Email.ReplyTo,
+		}
+	}
+	if len(tier.Channels) > 0 {
+		c.Channels = tier.Channels
+	}
+	if tier.Template != "" {
+		c.Template = tier.Template
+	}
+	return c, tier.Tone
+}
+
+// complaintInterval returns the interval until the next round of a complaint: the interval
+// of its tier, the time until its schedule fires next, or else the cadence after its
+// rounds so far. Counting the stored rounds keeps a growing cadence across restarts.
+func (s *Server) complaintInterval(complaintID string) time.Duration {
+	c, err := s.complaints.GetComplaint(complaintID)
+	if err != nil {
+		return 0
+	}
+	if tier, ok := s.currentTier(c); ok && tier.Interval > 0 {
+		return tier.Interval
+	}
+	if schedule := s.complaintSchedule(complaintID); schedule != nil {
+		now := s.clock.Now()
+		if next := schedule.Next(now); !next.IsZero() {
+			return next.Sub(now)
+		}
+	}
+	cadence := s.config.CadencePolicy()
+	return cadence.Delay(c.Attempts, cadence.Base(c.Attempts-1))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"complaint-escalator/internal/config"
+	"complaint-escalator/pkg/testutils"
+	"context"
+	"testing"
+	"time"
+)
+
+// testLadder sends to support twice, then once to a manager and then to legal
+var testLadder = []config.Tier{
+	{Name: "support", Email: config.EmailConfig{To: []string{"support@shop.example"}}, Attempts: 2},
+	{Name: "manager", Email: config.EmailConfig{To: []string{"manager@shop.example"}}, Channels: []string{"email"}, Tone: "firm", Interval: time.Hour, Attempts: 1},
+	{Name: "legal", Email: config.EmailConfig{To: []string{"legal@shop.example"}, CC: []string{"ombudsman@example.org"}}, Template: "Formal notice about order #1234."},
+}
+
+func TestEscalateClimbsTiers(t *testing.T) {
+	server := newTestServer(t)
+	server.tiers["default"] = testLadder
+
+	email := &recordingNotifier{}
+	logged := &recordingNotifier{}
+	server.registry.Register("email", email)
+	server.registry.Register("notification", logged)
+	server.poller = nil
+
+	expected := []string{"support@shop.example", "support@shop.example", "manager@shop.example", "legal@shop.example", "legal@shop.example"}
+	intervals := []time.Duration{server.config.Interval, server.config.Interval, time.Hour, server.config.Interval, server.config.Interval}
+	for i := range expected {
+		if err := server.escalate(context.Background(), "default"); err != nil {
+			t.Fatalf("Failed to escalate: %v", err)
+		}
+		if got := email.messages[i].To[0]; got != expected[i] {
+			t.Errorf("Round %d sent to %s want %s", i+1, got, expected[i])
+		}
+		if got := server.complaintInterval("default"); got != intervals[i] {
+			t.Errorf("Interval after round %d: got %v want %v", i+1, got, intervals[i])
+		}
+	}
+
+	// The manager tier only uses email, the others the channels of the complaint
+	if len(logged.messages) != 4 {
+		t.Errorf("Expected 4 log notifications, got %d", len(logged.messages))
+	}
+
+	legal := email.messages[3]
+	if legal.Text != "Formal notice about order #1234." || len(legal.CC) != 1 || legal.CC[0] != "ombudsman@example.org" {
+		t.Errorf("Legal tier not applied: %+v", legal)
+	}
+
+	c, err := server.complaints.GetComplaint("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Tier != 2 || c.TierAttempts != 2 || c.Attempts != 5 {
+		t.Errorf("Expected tier 2 with 2 of 5 attempts, got tier %d with %d of %d", c.Tier, c.TierAttempts, c.Attempts)
+	}
+}
+
+func TestEscalateClimbsTiersAfterElapsedTime(t *testing.T) {
+	cfg, err := config.LoadConfig(testutils.GetTestConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load test configuration: %v", err)
+	}
+	cfg.Tiers = []config.Tier{
+		{Name: "support", Email: config.EmailConfig{To: []string{"support@shop.example"}}, After: 48 * time.Hour},
+		{Name: "manager", Email: config.EmailConfig{To: []string{"manager@shop.example"}}},
+	}
+
+	clock := testutils.NewFakeClock(time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC))
+	server, err := NewServerWithClock(cfg, clock)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.Stop()
+
+	email := &recordingNotifier{}
+	server.registry.Register("email", email)
+	server.poller = nil
+
+	// The tier lasts 48 hours from its first send on the clock of the scheduler
+	steps := []time.Duration{0, 47 * time.Hour, time.Hour}
+	expected := []string{"support@shop.example", "support@shop.example", "manager@shop.example"}
+	for i, step := range steps {
+		clock.Advance(step)
+		if err := server.escalate(context.Background(), "default"); err != nil {
+			t.Fatalf("Failed to escalate: %v", err)
+		}
+		if got := email.messages[i].To[0]; got != expected[i] {
+			t.Errorf("Round %d after %v sent to %s want %s", i+1, step, got, expected[i])
+		}
+	}
+}
+
+func TestValidateTiers(t *testing.T) {
+	server := newTestServer(t)
+
+	if err := server.validateTiers(testLadder); err != nil {
+		t.Errorf("Expected valid ladder: %v", err)
+	}
+
+	tests := map[string][]config.Tier{
+		"tier without limit": {{Name: "support"}, {Name: "legal"}},
+		"negative attempts":  {{Name: "support", Attempts: -1}},
+		"unknown channel":    {{Name: "support", Channels: []string{"fax"}}},
+	}
+	for name, tiers := range tests {
+		if err := server.validateTiers(tiers); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"complaint-escalator/internal/config"
+	"complaint-escalator/internal/window"
+	"log"
+)
+
+// newSendWindow creates the window escalation sends must fall into, or returns nil when
+// sends may happen at any time
+func newSendWindow(cfg config.Config) (*window.Window, error) {
+	sw := cfg.SendWindow
+	if sw.TimeZone == "" && len(sw.Days) == 0 && sw.Start == "" && sw.End == "" && sw.Holidays == "" {
+		return nil, nil
+	}
+
+	w, err := window.New(sw.TimeZone, sw.Days, sw.Start, sw.End)
+	if err != nil {
+		return nil, err
+	}
+	if sw.Holidays != "" {
+		holidays, err := window.LoadCalendar(sw.Holidays)
+		if err != nil {
+			return nil, err
+		}
+		w.SetHolidays(holidays)
+		log.Printf("Loaded %d holiday days from %s", holidays.Len(), sw.Holidays)
+	}
+	return w, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"complaint-escalator/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewSendWindow(t *testing.T) {
+	// Without a send window sends happen at any time
+	w, err := newSendWindow(config.Config{})
+	if err != nil || w != nil {
+		t.Fatalf("Expected no send window, got %v (%v)", w, err)
+	}
+
+	holidays := filepath.Join(t.TempDir(), "holidays.ics")
+	calendar := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20251225\r\nRRULE:FREQ=YEARLY\r\nSUMMARY:Christmas Day\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	if err := os.WriteFile(holidays, []byte(calendar), 0600); err != nil {
+		t.Fatalf("Failed to write calendar: %v", err)
+	}
+
+	var cfg config.Config
+	cfg.SendWindow.TimeZone = "Asia/Singapore"
+	cfg.SendWindow.Days = []string{"mon", "tue", "wed", "thu", "fri"}
+	cfg.SendWindow.Start = "09:00"
+	cfg.SendWindow.End = "18:00"
+	cfg.SendWindow.Holidays = holidays
+
+	w, err = newSendWindow(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create send window: %v", err)
+	}
+
+	// Christmas Eve 2025 after hours in Singapore, Christmas Day is a holiday
+	at := time.Date(2025, 12, 24, 12, 0, 0, 0, time.UTC)
+	expected := time.Date(2025, 12, 26, 1, 0, 0, 0, time.UTC)
+	if got := w.Next(at); !got.Equal(expected) {
+		t.Errorf("Next(%v): got %v want %v", at, got, expected)
+	}
+
+	// A missing calendar fails at startup
+	cfg.SendWindow.Holidays = filepath.Join(t.TempDir(), "missing.ics")
+	if _, err := newSendWindow(cfg); err == nil {
+		t.Error("Expected error for a missing holiday calendar")
+	}
+}
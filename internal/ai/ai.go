@@ -1,6 +1,53 @@
 package ai
 
-func GenerateAIText(template string) string {
-	// TODO: Implement AI text generation logic
-	return template
+import (
+	"context"
+	"log"
+)
+
+// Prompt describes the text to generate for one escalation round
+type Prompt struct {
+	// Template is the complaint text that anchors the meaning of the generated text
+	Template string
+	// Round is the escalation round, starting at 1
+	Round int
+	// Tone optionally describes the tone of the text, such as "firm" or "formal legal"
+	Tone string
+}
+
+// Generator produces a fresh wording of a complaint template
+type Generator interface {
+	Generate(ctx context.Context, prompt Prompt) (string, error)
+}
+
+// Passthrough is a deterministic Generator that returns the template unchanged
+type Passthrough struct{}
+
+// Generate returns the template unchanged
+func (Passthrough) Generate(ctx context.Context, prompt Prompt) (string, error) {
+	return prompt.Template, nil
+}
+
+// NewGenerator creates an OpenAI-compatible generator whose output is checked against the
+// template, or a Passthrough generator when no endpoint is configured
+func NewGenerator(endpoint, model, apiKey string, maxAttempts int) (Generator, error) {
+	if endpoint == "" {
+		return Passthrough{}, nil
+	}
+	g, err := NewOpenAIGenerator(endpoint, model, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewGuardedGenerator(g, DefaultValidator(), maxAttempts), nil
+}
+
+// GenerateAIText generates the text of an escalation round and falls back to the
+// unchanged template when generation fails
+func GenerateAIText(ctx context.Context, g Generator, prompt Prompt) string {
+	text, err := g.Generate(ctx, prompt)
+	if err != nil || text == "" {
+		log.Printf("AI text generation failed, using the template: %v", err)
+		return prompt.Template
+	}
+	return text
 }
@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newFakeChatServer starts a local chat completions API that replies with the given text
+func newFakeChatServer(t *testing.T, reply string, requests *[]chatCompletionRequest) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req chatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		*requests = append(*requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": reply}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPassthrough(t *testing.T) {
+	text, err := Passthrough{}.Generate(context.Background(), Prompt{Template: "My order #1234 is late.", Round: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text != "My order #1234 is late." {
+		t.Errorf("Expected the template unchanged, got %s", text)
+	}
+}
+
+func TestNewGenerator(t *testing.T) {
+	g, err := NewGenerator("", "", "", 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := g.(Passthrough); !ok {
+		t.Errorf("Expected a passthrough generator without an endpoint, got %T", g)
+	}
+
+	g, err = NewGenerator("https://api.example.com/v1", "test-model", "key", 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := g.(*GuardedGenerator); !ok {
+		t.Errorf("Expected a guarded generator with an endpoint, got %T", g)
+	}
+
+	if _, err := NewGenerator("https://api.example.com/v1", "", "key", 3); err == nil {
+		t.Error("Expected error for missing model")
+	}
+}
+
+func TestOpenAIGenerator(t *testing.T) {
+	var requests []chatCompletionRequest
+	server := newFakeChatServer(t, "  Order #1234 still hasn't shown up.  ", &requests)
+
+	g, err := NewOpenAIGenerator(server.URL+"/v1/", "test-model", "test-key")
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+
+	text, err := g.Generate(context.Background(), Prompt{Template: "My order #1234 is late.", Round: 1})
+	if err != nil {
+		t.Fatalf("Failed to generate text: %v", err)
+	}
+	if text != "Order #1234 still hasn't shown up." {
+		t.Errorf("Unexpected generated text: %q", text)
+	}
+
+	// Follow-up rounds ask for different wording of the same template
+	if _, err := g.Generate(context.Background(), Prompt{Template: "My order #1234 is late.", Round: 3}); err != nil {
+		t.Fatalf("Failed to generate text: %v", err)
+	}
+
+	// Tiers of the escalation ladder ask for their own tone
+	if _, err := g.Generate(context.Background(), Prompt{Template: "My order #1234 is late.", Round: 5, Tone: "formal legal"}); err != nil {
+		t.Fatalf("Failed to generate text: %v", err)
+	}
+
+	if len(requests) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(requests))
+	}
+	if requests[0].Model != "test-model" || len(requests[0].Messages) != 2 || requests[0].Messages[0].Role != "system" {
+		t.Errorf("Unexpected request: %+v", requests[0])
+	}
+	if requests[0].Messages[1].Content != "My order #1234 is late." {
+		t.Errorf("First round should send the template as is, got %q", requests[0].Messages[1].Content)
+	}
+	if !strings.Contains(requests[1].Messages[1].Content, "follow-up number 2") || !strings.Contains(requests[1].Messages[1].Content, "My order #1234 is late.") {
+		t.Errorf("Follow-up round prompt missing context: %q", requests[1].Messages[1].Content)
+	}
+	if strings.Contains(requests[1].Messages[1].Content, "tone") {
+		t.Errorf("Prompt without tone should not ask for one: %q", requests[1].Messages[1].Content)
+	}
+	if !strings.HasPrefix(requests[2].Messages[1].Content, "Write in a formal legal tone.") {
+		t.Errorf("Prompt missing tone: %q", requests[2].Messages[1].Content)
+	}
+}
+
+func TestGenerateAITextFallsBackToTemplate(t *testing.T) {
+	var requests []chatCompletionRequest
+	server := newFakeChatServer(t, "reworded", &requests)
+
+	// The wrong key makes every request fail
+	g, err := NewOpenAIGenerator(server.URL+"/v1", "test-model", "wrong-key")
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+	if _, err := g.Generate(context.Background(), Prompt{Template: "template"}); err == nil {
+		t.Error("Expected error for rejected request")
+	}
+
+	text := GenerateAIText(context.Background(), g, Prompt{Template: "My order #1234 is late.", Round: 1})
+	if text != "My order #1234 is late." {
+		t.Errorf("Expected fallback to the template, got %s", text)
+	}
+}
@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// systemPrompt instructs the model to reword the complaint without changing its meaning
+const systemPrompt = "You rewrite customer complaints. Rephrase the complaint you are given so it reads " +
+	"as a fresh message, but keep its meaning, tone and every fact unchanged: names, order numbers, " +
+	"dates, amounts and placeholders such as {{name}} must appear exactly as written. " +
+	"Reply with the rewritten complaint only."
+
+// OpenAIGenerator generates text through an OpenAI-compatible chat completions API
+type OpenAIGenerator struct {
+	endpoint    string
+	model       string
+	apiKey      string
+	temperature float64
+	httpClient  *http.Client
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// NewOpenAIGenerator creates a new generator for the chat completions API at endpoint,
+// for example https://api.openai.com/v1
+func NewOpenAIGenerator(endpoint, model, apiKey string) (*OpenAIGenerator, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("endpoint cannot be empty")
+	}
+	if model == "" {
+		return nil, fmt.Errorf("model cannot be empty")
+	}
+
+	return &OpenAIGenerator{
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		model:       model,
+		apiKey:      apiKey,
+		temperature: 0.9,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}, nil
+}
+
+// Generate asks the model for a new wording of the template
+func (g *OpenAIGenerator) Generate(ctx context.Context, prompt Prompt) (string, error) {
+	if prompt.Template == "" {
+		return "", fmt.Errorf("template is required")
+	}
+
+	userPrompt := prompt.Template
+	if prompt.Round > 1 {
+		userPrompt = fmt.Sprintf("This is follow-up number %d about the same unresolved complaint. "+
+			"Use wording that differs from earlier messages.\n\n%s", prompt.Round-1, prompt.Template)
+	}
+	if prompt.Tone != "" {
+		userPrompt = fmt.Sprintf("Write in a %s tone.\n\n%s", prompt.Tone, userPrompt)
+	}
+
+	chatReq := chatCompletionRequest{
+		Model: g.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Temperature: g.temperature,
+	}
+
+	jsonData, err := json.Marshal(chatReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal chat request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", g.endpoint+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("chat completion failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var chatResp chatCompletionResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return "", fmt.Errorf("failed to decode response body: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("chat completion returned no choices")
+	}
+
+	text := strings.TrimSpace(chatResp.Choices[0].Message.Content)
+	if text == "" {
+		return "", fmt.Errorf("chat completion returned empty text")
+	}
+	return text, nil
+}
@@ -0,0 +1,155 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// placeholderPattern matches named placeholders such as {{name}} or {order_id}
+	placeholderPattern = regexp.MustCompile(`\{\{\s*[^{}]+?\s*\}\}|\{[A-Za-z_][A-Za-z0-9_]*\}`)
+	// numberPattern matches order numbers, amounts, dates and times such as 1234, 1,299.00, 2025-01-02 or 09:30
+	numberPattern = regexp.MustCompile(`\d+(?:[.,/:-]\d+)*`)
+)
+
+// DriftError reports why a generated variant no longer means the same as its template
+type DriftError struct {
+	// Missing lists the facts from the template that the variant dropped
+	Missing []string
+	// Reason describes a length violation
+	Reason string
+}
+
+func (e *DriftError) Error() string {
+	if len(e.Missing) > 0 {
+		return fmt.Sprintf("generated text drops required facts: %s", strings.Join(e.Missing, ", "))
+	}
+	return fmt.Sprintf("generated text rejected: %s", e.Reason)
+}
+
+// Validator rejects generated variants that drift from the meaning of their template
+type Validator struct {
+	// MinRatio and MaxRatio bound the variant length relative to the template length
+	MinRatio float64
+	MaxRatio float64
+	// MaxLength is an absolute upper bound on the variant length in characters. Zero means no bound.
+	MaxLength int
+}
+
+// DefaultValidator returns a validator that allows variants between half and twice the template length
+func DefaultValidator() Validator {
+	return Validator{
+		MinRatio: 0.5,
+		MaxRatio: 2.0,
+	}
+}
+
+// ExtractFacts returns the placeholders and numbers of a template that a variant must keep verbatim
+func ExtractFacts(template string) []string {
+	var facts []string
+	seen := make(map[string]bool)
+
+	// Numbers inside placeholders belong to the placeholder
+	rest := placeholderPattern.ReplaceAllStringFunc(template, func(p string) string {
+		if !seen[p] {
+			seen[p] = true
+			facts = append(facts, p)
+		}
+		return " "
+	})
+
+	for _, n := range numberPattern.FindAllString(rest, -1) {
+		if !seen[n] {
+			seen[n] = true
+			facts = append(facts, n)
+		}
+	}
+	return facts
+}
+
+// Validate checks that the variant keeps every fact of the template and stays within the length bounds
+func (v Validator) Validate(template, variant string) error {
+	templateLen := utf8.RuneCountInString(strings.TrimSpace(template))
+	variantLen := utf8.RuneCountInString(strings.TrimSpace(variant))
+
+	if variantLen == 0 {
+		return &DriftError{Reason: "text is empty"}
+	}
+	if v.MaxLength > 0 && variantLen > v.MaxLength {
+		return &DriftError{Reason: fmt.Sprintf("length %d exceeds the maximum of %d", variantLen, v.MaxLength)}
+	}
+	if v.MinRatio > 0 && float64(variantLen) < v.MinRatio*float64(templateLen) {
+		return &DriftError{Reason: fmt.Sprintf("length %d is too short for a template of length %d", variantLen, templateLen)}
+	}
+	if v.MaxRatio > 0 && float64(variantLen) > v.MaxRatio*float64(templateLen) {
+		return &DriftError{Reason: fmt.Sprintf("length %d is too long for a template of length %d", variantLen, templateLen)}
+	}
+
+	// Numbers count only as whole numbers of the variant, so 12 is not kept by 1234 or 1.12
+	numbers := make(map[string]bool)
+	for _, n := range numberPattern.FindAllString(variant, -1) {
+		numbers[n] = true
+	}
+
+	var missing []string
+	for _, fact := range ExtractFacts(template) {
+		kept := numbers[fact]
+		if placeholderPattern.MatchString(fact) {
+			kept = strings.Contains(variant, fact)
+		}
+		if !kept {
+			missing = append(missing, fact)
+		}
+	}
+	if len(missing) > 0 {
+		return &DriftError{Missing: missing}
+	}
+	return nil
+}
+
+// GuardedGenerator retries a Generator until it produces a variant that passes validation
+type GuardedGenerator struct {
+	generator   Generator
+	validator   Validator
+	maxAttempts int
+}
+
+// defaultMaxAttempts is the number of generation attempts when none is configured
+const defaultMaxAttempts = 3
+
+// NewGuardedGenerator creates a new generator that makes at most maxAttempts attempts
+func NewGuardedGenerator(generator Generator, validator Validator, maxAttempts int) *GuardedGenerator {
+	if maxAttempts < 1 {
+		maxAttempts = defaultMaxAttempts
+	}
+	return &GuardedGenerator{
+		generator:   generator,
+		validator:   validator,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Generate returns the first valid variant, or the last error when every attempt fails
+func (g *GuardedGenerator) Generate(ctx context.Context, prompt Prompt) (string, error) {
+	var lastErr error
+	for attempt := 1; attempt <= g.maxAttempts; attempt++ {
+		text, err := g.generator.Generate(ctx, prompt)
+		if err == nil {
+			err = g.validator.Validate(prompt.Template, text)
+			if err == nil {
+				return text, nil
+			}
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		log.Printf("AI text attempt %d/%d rejected: %v", attempt, g.maxAttempts, err)
+		lastErr = err
+	}
+	return "", fmt.Errorf("no valid text after %d attempts: %w", g.maxAttempts, lastErr)
+}
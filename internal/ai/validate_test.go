@@ -0,0 +1,153 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// scriptedGenerator returns the given replies in order
+type scriptedGenerator struct {
+	replies []string
+	calls   int
+}
+
+func (g *scriptedGenerator) Generate(ctx context.Context, prompt Prompt) (string, error) {
+	if g.calls >= len(g.replies) {
+		return "", errors.New("no more replies")
+	}
+	reply := g.replies[g.calls]
+	g.calls++
+	return reply, nil
+}
+
+func TestExtractFacts(t *testing.T) {
+	template := "Dear {{company}}, my order #88231 of $1,299.00 placed on 2025-01-02 at 09:30 was never delivered. Contact {customer_name}."
+
+	facts := ExtractFacts(template)
+	expected := []string{"{{company}}", "{customer_name}", "88231", "1,299.00", "2025-01-02", "09:30"}
+	if !reflect.DeepEqual(facts, expected) {
+		t.Errorf("Expected facts %v, got %v", expected, facts)
+	}
+}
+
+func TestValidatorValidate(t *testing.T) {
+	template := "My order #88231 of $49.99 placed on 2025-01-02 has still not arrived, {{name}}."
+	v := DefaultValidator()
+
+	tests := []struct {
+		name    string
+		variant string
+		missing []string
+		valid   bool
+	}{
+		{
+			name:    "reworded with all facts",
+			variant: "{{name}}, order #88231 ($49.99, ordered 2025-01-02) is still missing.",
+			valid:   true,
+		},
+		{
+			name:    "dropped order number",
+			variant: "{{name}}, my order of $49.99 placed on 2025-01-02 still has not arrived.",
+			missing: []string{"88231"},
+		},
+		{
+			name:    "changed amount and placeholder",
+			variant: "Hello, my order #88231 of $59.99 placed on 2025-01-02 has still not arrived.",
+			missing: []string{"{{name}}", "49.99"},
+		},
+		{
+			name:    "too short",
+			variant: "88231 49.99 2025-01-02 {{name}}",
+		},
+		{
+			name:    "empty",
+			variant: "   ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(template, tt.variant)
+			if tt.valid {
+				if err != nil {
+					t.Errorf("Expected variant to be valid, got %v", err)
+				}
+				return
+			}
+
+			var drift *DriftError
+			if !errors.As(err, &drift) {
+				t.Fatalf("Expected DriftError, got %v", err)
+			}
+			if tt.missing != nil && !reflect.DeepEqual(drift.Missing, tt.missing) {
+				t.Errorf("Expected missing %v, got %v", tt.missing, drift.Missing)
+			}
+		})
+	}
+
+	// Numbers must be kept whole, not as part of a longer number
+	short := "Please refund the 12 euros for order 7 by 2025-01-02."
+	for _, variant := range []string{
+		"Please refund the 1234 euros for order 7 by 2025-01-02.",
+		"Please refund the 1.12 euros for order 7 by 2025-01-02.",
+	} {
+		var drift *DriftError
+		if err := v.Validate(short, variant); !errors.As(err, &drift) || !reflect.DeepEqual(drift.Missing, []string{"12"}) {
+			t.Errorf("Expected 12 to be missing from %q, got %v", variant, err)
+		}
+	}
+	if err := v.Validate(short, "Refund the 12 euros of order 7 by 2025-01-02, please."); err != nil {
+		t.Errorf("Expected whole numbers to be kept: %v", err)
+	}
+
+	// The absolute maximum applies on top of the ratio
+	v.MaxLength = 20
+	if err := v.Validate(template, template); err == nil {
+		t.Error("Expected error for text longer than the maximum length")
+	}
+}
+
+func TestGuardedGeneratorRetries(t *testing.T) {
+	template := "Order #88231 has not arrived."
+	g := &scriptedGenerator{replies: []string{
+		"My order still has not arrived.",
+		"Order #88231 is still not here.",
+	}}
+
+	guarded := NewGuardedGenerator(g, DefaultValidator(), 3)
+	text, err := guarded.Generate(context.Background(), Prompt{Template: template})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text != "Order #88231 is still not here." {
+		t.Errorf("Expected the second variant, got %s", text)
+	}
+	if g.calls != 2 {
+		t.Errorf("Expected 2 generation calls, got %d", g.calls)
+	}
+}
+
+func TestGuardedGeneratorFallsBackToTemplate(t *testing.T) {
+	template := "Order #88231 has not arrived."
+	g := &scriptedGenerator{replies: []string{
+		"My order has not arrived.",
+		"The order is missing.",
+		"Order #1 has not arrived.",
+	}}
+
+	guarded := NewGuardedGenerator(g, DefaultValidator(), 2)
+	if _, err := guarded.Generate(context.Background(), Prompt{Template: template}); err == nil {
+		t.Error("Expected error when every variant drifts")
+	}
+	if g.calls != 2 {
+		t.Errorf("Expected 2 generation calls, got %d", g.calls)
+	}
+
+	// GenerateAIText sends the template instead
+	g.calls = 0
+	if text := GenerateAIText(context.Background(), guarded, Prompt{Template: template}); text != template {
+		t.Errorf("Expected fallback to the template, got %s", text)
+	}
+}
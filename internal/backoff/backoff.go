@@ -0,0 +1,210 @@
+package backoff
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Strategy describes how delays grow from one attempt to the next
+type Strategy string
+
+const (
+	// StrategyConstant waits the initial delay every time
+	StrategyConstant Strategy = "constant"
+	// StrategyLinear adds the step to the delay after every attempt
+	StrategyLinear Strategy = "linear"
+	// StrategyExponential multiplies the delay by the multiplier after every attempt
+	StrategyExponential Strategy = "exponential"
+)
+
+// Jitter describes how delays are randomized so that retries do not line up
+type Jitter string
+
+const (
+	// JitterNone keeps delays exact
+	JitterNone Jitter = "none"
+	// JitterFull waits a random time between zero and the delay
+	JitterFull Jitter = "full"
+	// JitterDecorrelated waits a random time between the initial delay and three times
+	// the previous delay, independent of the strategy
+	JitterDecorrelated Jitter = "decorrelated"
+)
+
+// defaultMultiplier is the growth of exponential delays without a multiplier
+const defaultMultiplier = 2
+
+// random returns a random number in [0, 1). Tests replace it to get predictable jitter.
+var random = rand.Float64
+
+// Policy describes the delays between attempts. The zero Policy has no delays.
+type Policy struct {
+	// Strategy is StrategyConstant (the default), StrategyLinear or StrategyExponential
+	Strategy Strategy `yaml:"strategy,omitempty"`
+	// Initial is the delay before the second attempt
+	Initial time.Duration `yaml:"initial"`
+	// Step is added per attempt by StrategyLinear. It defaults to Initial.
+	Step time.Duration `yaml:"step,omitempty"`
+	// Multiplier is the growth per attempt of StrategyExponential. It defaults to 2.
+	Multiplier float64 `yaml:"multiplier,omitempty"`
+	// Jitter is JitterNone (the default), JitterFull or JitterDecorrelated
+	Jitter Jitter `yaml:"jitter,omitempty"`
+	// MaxDelay caps every delay. Zero means no cap.
+	MaxDelay time.Duration `yaml:"max_delay,omitempty"`
+	// MaxAttempts is the number of attempts after which the policy is exhausted. Zero means no limit.
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+}
+
+// Constant returns a policy that always waits d
+func Constant(d time.Duration) Policy {
+	return Policy{Strategy: StrategyConstant, Initial: d}
+}
+
+// UnmarshalYAML reads a policy from a mapping, or from a single duration such as
+// "2m" for a constant policy
+func (p *Policy) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var d time.Duration
+		if err := value.Decode(&d); err != nil {
+			return fmt.Errorf("backoff must be a duration or a policy: %w", err)
+		}
+		*p = Constant(d)
+		return nil
+	}
+
+	// The alias type has no UnmarshalYAML method, so decoding it does not recurse
+	type policy Policy
+	var decoded policy
+	if err := value.Decode(&decoded); err != nil {
+		return err
+	}
+	*p = Policy(decoded)
+	return nil
+}
+
+// IsZero reports whether the policy is unset
+func (p Policy) IsZero() bool {
+	return p == Policy{}
+}
+
+// Validate validates the policy settings
+func (p Policy) Validate() error {
+	switch p.Strategy {
+	case "", StrategyConstant, StrategyLinear, StrategyExponential:
+	default:
+		return fmt.Errorf("unknown backoff strategy %q", p.Strategy)
+	}
+	switch p.Jitter {
+	case "", JitterNone, JitterFull, JitterDecorrelated:
+	default:
+		return fmt.Errorf("unknown jitter %q", p.Jitter)
+	}
+	if p.Initial < 0 || p.Step < 0 || p.MaxDelay < 0 {
+		return fmt.Errorf("delays cannot be negative")
+	}
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		return fmt.Errorf("multiplier must be at least 1")
+	}
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("max attempts cannot be negative")
+	}
+	if p.MaxDelay > 0 && p.MaxDelay < p.Initial {
+		return fmt.Errorf("max delay %v is less than the initial delay %v", p.MaxDelay, p.Initial)
+	}
+	return nil
+}
+
+// Exhausted reports whether no attempt may follow the given number of attempts
+func (p Policy) Exhausted(attempts int) bool {
+	return p.MaxAttempts > 0 && attempts >= p.MaxAttempts
+}
+
+// Base returns the delay after the given attempt, starting at 1, without jitter
+func (p Policy) Base(attempt int) time.Duration {
+	attempt = max(attempt, 1)
+
+	var delay float64
+	switch p.Strategy {
+	case StrategyLinear:
+		step := p.Step
+		if step == 0 {
+			step = p.Initial
+		}
+		delay = float64(p.Initial) + float64(attempt-1)*float64(step)
+	case StrategyExponential:
+		multiplier := p.Multiplier
+		if multiplier == 0 {
+			multiplier = defaultMultiplier
+		}
+		delay = float64(p.Initial) * math.Pow(multiplier, float64(attempt-1))
+	default:
+		delay = float64(p.Initial)
+	}
+	return p.capDelay(delay)
+}
+
+// Delay returns the delay after the given attempt, starting at 1, with jitter.
+// previous is the delay before that attempt, which decorrelated jitter grows from.
+func (p Policy) Delay(attempt int, previous time.Duration) time.Duration {
+	switch p.Jitter {
+	case JitterFull:
+		return time.Duration(random() * float64(p.Base(attempt)))
+	case JitterDecorrelated:
+		low := float64(p.Initial)
+		high := max(3*float64(previous), low)
+		return p.capDelay(low + random()*(high-low))
+	default:
+		return p.Base(attempt)
+	}
+}
+
+// String describes the policy for logs
+func (p Policy) String() string {
+	strategy := p.Strategy
+	if strategy == "" {
+		strategy = StrategyConstant
+	}
+	s := fmt.Sprintf("%s %v", strategy, p.Initial)
+	if p.Jitter != "" && p.Jitter != JitterNone {
+		s += fmt.Sprintf(", %s jitter", p.Jitter)
+	}
+	if p.MaxDelay > 0 {
+		s += fmt.Sprintf(", max %v", p.MaxDelay)
+	}
+	if p.MaxAttempts > 0 {
+		s += fmt.Sprintf(", %d attempts", p.MaxAttempts)
+	}
+	return s
+}
+
+// capDelay limits a delay to MaxDelay and to the largest duration
+func (p Policy) capDelay(delay float64) time.Duration {
+	limit := float64(math.MaxInt64)
+	if p.MaxDelay > 0 {
+		limit = float64(p.MaxDelay)
+	}
+	if delay >= limit {
+		if p.MaxDelay > 0 {
+			return p.MaxDelay
+		}
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// Sleep waits for d or until the context is done
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
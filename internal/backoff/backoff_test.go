@@ -0,0 +1,162 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fixRandom makes jitter return fraction of its range for the duration of the test
+func fixRandom(t *testing.T, fraction float64) {
+	t.Helper()
+	original := random
+	random = func() float64 { return fraction }
+	t.Cleanup(func() { random = original })
+}
+
+func TestPolicyBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		expected []time.Duration
+	}{
+		{"constant", Constant(2 * time.Minute), []time.Duration{2 * time.Minute, 2 * time.Minute, 2 * time.Minute}},
+		{"linear", Policy{Strategy: StrategyLinear, Initial: time.Minute}, []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute}},
+		{"linear with step", Policy{Strategy: StrategyLinear, Initial: time.Minute, Step: 30 * time.Second}, []time.Duration{time.Minute, 90 * time.Second, 2 * time.Minute}},
+		{"exponential", Policy{Strategy: StrategyExponential, Initial: time.Second}, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}},
+		{"exponential with multiplier", Policy{Strategy: StrategyExponential, Initial: time.Second, Multiplier: 1.5}, []time.Duration{time.Second, 1500 * time.Millisecond, 2250 * time.Millisecond}},
+		{"capped", Policy{Strategy: StrategyExponential, Initial: time.Minute, MaxDelay: 3 * time.Minute}, []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, expected := range tt.expected {
+				if got := tt.policy.Base(i + 1); got != expected {
+					t.Errorf("Base(%d): got %v want %v", i+1, got, expected)
+				}
+			}
+		})
+	}
+
+	// Large attempts do not overflow
+	huge := Policy{Strategy: StrategyExponential, Initial: time.Hour}
+	if got := huge.Base(200); got <= 0 {
+		t.Errorf("Expected a positive delay for a large attempt, got %v", got)
+	}
+}
+
+func TestPolicyJitter(t *testing.T) {
+	fixRandom(t, 0.5)
+
+	full := Policy{Strategy: StrategyExponential, Initial: time.Second, Jitter: JitterFull}
+	if got := full.Delay(3, 0); got != 2*time.Second {
+		t.Errorf("Full jitter: got %v want %v", got, 2*time.Second)
+	}
+
+	// Decorrelated jitter picks between the initial delay and three times the previous one
+	decorrelated := Policy{Initial: time.Second, Jitter: JitterDecorrelated, MaxDelay: 10 * time.Second}
+	if got := decorrelated.Delay(1, 0); got != time.Second {
+		t.Errorf("Decorrelated jitter without a previous delay: got %v want %v", got, time.Second)
+	}
+	if got := decorrelated.Delay(2, 3*time.Second); got != 5*time.Second {
+		t.Errorf("Decorrelated jitter: got %v want %v", got, 5*time.Second)
+	}
+	if got := decorrelated.Delay(3, 20*time.Second); got != 10*time.Second {
+		t.Errorf("Decorrelated jitter should be capped: got %v want %v", got, 10*time.Second)
+	}
+
+	// Without jitter the delay is exact
+	fixRandom(t, 0.1)
+	exact := Policy{Strategy: StrategyLinear, Initial: time.Minute}
+	if got := exact.Delay(2, time.Minute); got != 2*time.Minute {
+		t.Errorf("No jitter: got %v want %v", got, 2*time.Minute)
+	}
+}
+
+func TestPolicyExhausted(t *testing.T) {
+	p := Policy{Initial: time.Second, MaxAttempts: 3}
+	if p.Exhausted(2) || !p.Exhausted(3) {
+		t.Error("Expected the policy to be exhausted after 3 attempts")
+	}
+	if Constant(time.Second).Exhausted(1000) {
+		t.Error("Expected no limit without max attempts")
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	valid := []Policy{
+		{},
+		Constant(time.Minute),
+		{Strategy: StrategyExponential, Initial: time.Second, Multiplier: 3, Jitter: JitterFull, MaxDelay: time.Hour, MaxAttempts: 5},
+	}
+	for _, p := range valid {
+		if err := p.Validate(); err != nil {
+			t.Errorf("Expected %v to be valid: %v", p, err)
+		}
+	}
+
+	invalid := []Policy{
+		{Strategy: "fibonacci", Initial: time.Second},
+		{Initial: time.Second, Jitter: "some"},
+		{Initial: -time.Second},
+		{Strategy: StrategyExponential, Initial: time.Second, Multiplier: 0.5},
+		{Initial: time.Second, MaxAttempts: -1},
+		{Initial: time.Hour, MaxDelay: time.Minute},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", p)
+		}
+	}
+}
+
+func TestPolicyUnmarshalYAML(t *testing.T) {
+	var cfg struct {
+		Scalar Policy `yaml:"scalar"`
+		Mapped Policy `yaml:"mapped"`
+		Unset  Policy `yaml:"unset"`
+	}
+	err := yaml.Unmarshal([]byte(`
+scalar: 2m
+mapped:
+  strategy: exponential
+  initial: 30s
+  multiplier: 3
+  jitter: decorrelated
+  max_delay: 1h
+  max_attempts: 5
+`), &cfg)
+	if err != nil {
+		t.Fatalf("Failed to parse policies: %v", err)
+	}
+
+	if cfg.Scalar != Constant(2*time.Minute) {
+		t.Errorf("Unexpected scalar policy: %+v", cfg.Scalar)
+	}
+	expected := Policy{Strategy: StrategyExponential, Initial: 30 * time.Second, Multiplier: 3, Jitter: JitterDecorrelated, MaxDelay: time.Hour, MaxAttempts: 5}
+	if cfg.Mapped != expected {
+		t.Errorf("Unexpected policy: got %+v want %+v", cfg.Mapped, expected)
+	}
+	if !cfg.Unset.IsZero() {
+		t.Errorf("Expected an unset policy, got %+v", cfg.Unset)
+	}
+
+	if err := yaml.Unmarshal([]byte(`scalar: often`), &cfg); err == nil {
+		t.Error("Expected error for an invalid duration")
+	}
+}
+
+func TestSleep(t *testing.T) {
+	if err := Sleep(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("Expected the wait to finish, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the wait to stop with the context, got %v", err)
+	}
+}
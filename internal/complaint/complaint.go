@@ -0,0 +1,248 @@
+package complaint
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// State represents the lifecycle state of a complaint
+type State string
+
+const (
+	// StateOpen is a complaint that has been filed but is not being escalated yet
+	StateOpen State = "open"
+	// StateEscalating is a complaint that the scheduler is actively re-sending
+	StateEscalating State = "escalating"
+	// StatePaused is a complaint whose escalation has been suspended
+	StatePaused State = "paused"
+	// StateResponded is a complaint the company replied to. Escalation stops until a human
+	// resumes, resolves or abandons it.
+	StateResponded State = "responded"
+	// StateResolved is a complaint that the company has resolved
+	StateResolved State = "resolved"
+	// StateAbandoned is a complaint that was given up on
+	StateAbandoned State = "abandoned"
+)
+
+// transitions lists the states each state may move to
+var transitions = map[State][]State{
+	StateOpen:       {StateEscalating, StateResolved, StateAbandoned},
+	StateEscalating: {StatePaused, StateResponded, StateResolved, StateAbandoned},
+	StatePaused:     {StateEscalating, StateResponded, StateResolved, StateAbandoned},
+	StateResponded:  {StateEscalating, StateResolved, StateAbandoned},
+}
+
+var (
+	// ErrNotFound is returned when a complaint does not exist
+	ErrNotFound = errors.New("complaint not found")
+	// ErrAlreadyExists is returned when a complaint with the same id already exists
+	ErrAlreadyExists = errors.New("complaint already exists")
+	// ErrInvalidTransition is returned when a state change is not allowed
+	ErrInvalidTransition = errors.New("invalid state transition")
+)
+
+// validID matches ids that are safe to use in URLs and storage keys
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Valid reports whether s is a known state
+func (s State) Valid() bool {
+	switch s {
+	case StateOpen, StateEscalating, StatePaused, StateResponded, StateResolved, StateAbandoned:
+		return true
+	}
+	return false
+}
+
+// Terminal reports whether no further transitions are possible from s
+func (s State) Terminal() bool {
+	return s == StateResolved || s == StateAbandoned
+}
+
+// CanTransition reports whether a complaint may move from one state to another
+func CanTransition(from, to State) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Recipients holds the addresses a complaint is sent to
+type Recipients struct {
+	To      []string `json:"to"`
+	CC      []string `json:"cc,omitempty"`
+	BCC     []string `json:"bcc,omitempty"`
+	ReplyTo string   `json:"reply_to,omitempty"`
+}
+
+// Complaint represents a single grievance that is escalated against a company
+type Complaint struct {
+	ID         string     `json:"id"`
+	Subject    string     `json:"subject"`
+	Template   string     `json:"template"`
+	Recipients Recipients `json:"recipients"`
+	Channels   []string   `json:"channels"`
+	State      State      `json:"state"`
+	Attempts   int        `json:"attempts"`
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	NextSendAt *time.Time `json:"next_send_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	// RespondedAt is when the last reply from the company was received
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	// Tier is the current rung of the escalation ladder, starting at 0. TierSince and
+	// TierAttempts count the time since the first send and the sends in that tier.
+	Tier         int        `json:"tier"`
+	TierSince    *time.Time `json:"tier_since,omitempty"`
+	TierAttempts int        `json:"tier_attempts"`
+}
+
+// TierLimit says when a complaint moves on from a tier of the escalation ladder:
+// after a number of unanswered sends or once the tier has lasted a duration.
+// Zero values mean no limit.
+type TierLimit struct {
+	Attempts int
+	After    time.Duration
+}
+
+// New creates a new open complaint. A random id is generated when id is empty.
+func New(id, subject, template string, recipients Recipients, channels []string, now time.Time) (Complaint, error) {
+	if id == "" {
+		id = NewID()
+	}
+
+	c := Complaint{
+		ID:         id,
+		Subject:    subject,
+		Template:   template,
+		Recipients: recipients,
+		Channels:   channels,
+		State:      StateOpen,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := c.Validate(); err != nil {
+		return Complaint{}, err
+	}
+	return c, nil
+}
+
+// NewID generates a random complaint id
+func NewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("c%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// FieldError describes why a single field of a complaint is invalid
+type FieldError struct {
+	Field string
+	// Code is a machine-readable reason, such as "required" or "invalid"
+	Code    string
+	Message string
+}
+
+// ValidationError lists every invalid field of a complaint
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate validates the complaint fields. It returns a *ValidationError with every invalid field.
+func (c Complaint) Validate() error {
+	var fields []FieldError
+	switch {
+	case c.ID == "":
+		fields = append(fields, FieldError{"id", "required", "id is required"})
+	case !validID.MatchString(c.ID):
+		fields = append(fields, FieldError{"id", "invalid", "id may only contain letters, digits, '.', '_' and '-'"})
+	}
+	if c.Subject == "" {
+		fields = append(fields, FieldError{"subject", "required", "subject is required"})
+	}
+	if c.Template == "" {
+		fields = append(fields, FieldError{"template", "required", "template is required"})
+	}
+	if len(c.Channels) == 0 {
+		fields = append(fields, FieldError{"channels", "required", "at least one channel is required"})
+	}
+	if !c.State.Valid() {
+		fields = append(fields, FieldError{"state", "invalid", fmt.Sprintf("unknown state %q", c.State)})
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// Transition moves the complaint to the given state if the transition is allowed
+func (c *Complaint) Transition(to State, now time.Time) error {
+	if !CanTransition(c.State, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, c.State, to)
+	}
+
+	c.State = to
+	c.UpdatedAt = now
+	if to != StateEscalating {
+		c.NextSendAt = nil
+	}
+	if to == StateResolved {
+		c.ResolvedAt = &now
+	}
+	if to == StateResponded {
+		c.RespondedAt = &now
+	}
+	return nil
+}
+
+// AdvanceTier moves the complaint up one tier of the ladder described by limits when
+// its current tier has reached its limit. The last tier is never left. It reports
+// whether the complaint moved up.
+func (c *Complaint) AdvanceTier(limits []TierLimit, now time.Time) bool {
+	if c.Tier >= len(limits)-1 {
+		return false
+	}
+
+	limit := limits[c.Tier]
+	reached := limit.Attempts > 0 && c.TierAttempts >= limit.Attempts
+	if limit.After > 0 && c.TierSince != nil && now.Sub(*c.TierSince) >= limit.After {
+		reached = true
+	}
+	if !reached {
+		return false
+	}
+
+	c.Tier++
+	c.TierSince = nil
+	c.TierAttempts = 0
+	c.UpdatedAt = now
+	return true
+}
+
+// RecordSend records a successful escalation send
+func (c *Complaint) RecordSend(now time.Time) {
+	c.Attempts++
+	c.TierAttempts++
+	if c.TierSince == nil {
+		c.TierSince = &now
+	}
+	c.LastSentAt = &now
+	c.UpdatedAt = now
+}
@@ -0,0 +1,162 @@
+package complaint
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestComplaint(t *testing.T, id string, now time.Time) Complaint {
+	t.Helper()
+	c, err := New(id, "Late delivery", "My order #1234 has not arrived.", Recipients{To: []string{"support@example.com"}}, []string{"email"}, now)
+	if err != nil {
+		t.Fatalf("Failed to create complaint: %v", err)
+	}
+	return c
+}
+
+func TestNewComplaint(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	c := newTestComplaint(t, "", now)
+
+	if c.ID == "" {
+		t.Error("Expected a generated id")
+	}
+	if c.State != StateOpen {
+		t.Errorf("Expected state %s, got %s", StateOpen, c.State)
+	}
+	if !c.CreatedAt.Equal(now) {
+		t.Errorf("Expected created at %v, got %v", now, c.CreatedAt)
+	}
+
+	// Missing required fields are rejected
+	if _, err := New("x", "", "template", Recipients{}, []string{"email"}, now); err == nil {
+		t.Error("Expected error for missing subject")
+	}
+	if _, err := New("x", "subject", "", Recipients{}, []string{"email"}, now); err == nil {
+		t.Error("Expected error for missing template")
+	}
+	if _, err := New("x", "subject", "template", Recipients{}, nil, now); err == nil {
+		t.Error("Expected error for missing channels")
+	}
+	if _, err := New("a/b", "subject", "template", Recipients{}, []string{"email"}, now); err == nil {
+		t.Error("Expected error for invalid id")
+	}
+}
+
+func TestComplaintTransitions(t *testing.T) {
+	tests := []struct {
+		from, to State
+		allowed  bool
+	}{
+		{StateOpen, StateEscalating, true},
+		{StateOpen, StatePaused, false},
+		{StateEscalating, StatePaused, true},
+		{StatePaused, StateEscalating, true},
+		{StateEscalating, StateResolved, true},
+		{StatePaused, StateAbandoned, true},
+		{StateResolved, StateEscalating, false},
+		{StateAbandoned, StateOpen, false},
+		{StateEscalating, StateResponded, true},
+		{StateOpen, StateResponded, false},
+		{StateResponded, StateEscalating, true},
+		{StateResponded, StatePaused, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.allowed {
+			t.Errorf("CanTransition(%s, %s) = %v want %v", tt.from, tt.to, got, tt.allowed)
+		}
+	}
+
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	c := newTestComplaint(t, "c1", now)
+
+	if err := c.Transition(StatePaused, now); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition, got %v", err)
+	}
+	if err := c.Transition(StateEscalating, now); err != nil {
+		t.Fatalf("Unexpected transition error: %v", err)
+	}
+
+	respondedAt := now.Add(time.Minute)
+	if err := c.Transition(StateResponded, respondedAt); err != nil {
+		t.Fatalf("Unexpected transition error: %v", err)
+	}
+	if c.RespondedAt == nil || !c.RespondedAt.Equal(respondedAt) || c.NextSendAt != nil {
+		t.Errorf("Expected responded at %v without next send, got %v", respondedAt, c.RespondedAt)
+	}
+
+	resolvedAt := now.Add(time.Hour)
+	if err := c.Transition(StateResolved, resolvedAt); err != nil {
+		t.Fatalf("Unexpected transition error: %v", err)
+	}
+	if c.ResolvedAt == nil || !c.ResolvedAt.Equal(resolvedAt) {
+		t.Errorf("Expected resolved at %v, got %v", resolvedAt, c.ResolvedAt)
+	}
+	if !c.State.Terminal() {
+		t.Error("Resolved state should be terminal")
+	}
+}
+
+func TestComplaintAdvanceTier(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	c := newTestComplaint(t, "c1", now)
+
+	// Support gets three attempts, a manager one day, legal everything after
+	limits := []TierLimit{{Attempts: 3}, {After: 24 * time.Hour}, {}}
+
+	for i := 0; i < 3; i++ {
+		if c.AdvanceTier(limits, now) {
+			t.Fatalf("Complaint moved up before attempt %d", i+1)
+		}
+		c.RecordSend(now)
+	}
+	if !c.AdvanceTier(limits, now) || c.Tier != 1 || c.TierAttempts != 0 || c.TierSince != nil {
+		t.Fatalf("Expected to move up to tier 1 after 3 attempts, got tier %d with %d attempts", c.Tier, c.TierAttempts)
+	}
+
+	// The time in a tier counts from its first send
+	c.RecordSend(now)
+	if c.AdvanceTier(limits, now.Add(23*time.Hour)) {
+		t.Error("Complaint moved up before the tier lasted a day")
+	}
+	if !c.AdvanceTier(limits, now.Add(24*time.Hour)) || c.Tier != 2 {
+		t.Errorf("Expected to move up to tier 2 after a day, got tier %d", c.Tier)
+	}
+
+	// The last tier is never left
+	for i := 0; i < 5; i++ {
+		c.RecordSend(now)
+	}
+	if c.AdvanceTier(limits, now.Add(365*24*time.Hour)) || c.Tier != 2 {
+		t.Errorf("Expected to stay in the last tier, got tier %d", c.Tier)
+	}
+	if c.Attempts != 9 {
+		t.Errorf("Expected 9 attempts in total, got %d", c.Attempts)
+	}
+}
+
+func TestValidateListsEveryField(t *testing.T) {
+	c := Complaint{ID: "a/b", State: StateOpen, Channels: []string{"email"}}
+
+	err := c.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+
+	expected := []FieldError{
+		{"id", "invalid", "id may only contain letters, digits, '.', '_' and '-'"},
+		{"subject", "required", "subject is required"},
+		{"template", "required", "template is required"},
+	}
+	if len(validationErr.Fields) != len(expected) {
+		t.Fatalf("Expected %d invalid fields, got %+v", len(expected), validationErr.Fields)
+	}
+	for i, f := range expected {
+		if validationErr.Fields[i] != f {
+			t.Errorf("Field %d: got %+v want %+v", i, validationErr.Fields[i], f)
+		}
+	}
+}
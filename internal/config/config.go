@@ -1,6 +1,9 @@
 package config
 
 import (
+	"complaint-escalator/internal/backoff"
+	"complaint-escalator/internal/cron"
+	"fmt"
 	"os"
 	"time"
 
@@ -9,22 +12,157 @@ import (
 
 type Config struct {
 	Interval time.Duration `yaml:"interval"`
-	Backoff  time.Duration `yaml:"backoff"`
-	Template string        `yaml:"template"`
-	Channels []string      `yaml:"channels"`
+	// Cadence optionally grows the interval between escalation rounds. Its initial
+	// delay defaults to Interval.
+	Cadence backoff.Policy `yaml:"cadence,omitempty"`
+	// Backoff spaces the retries of a failed round. It is a duration such as "2m"
+	// or a policy with strategy, jitter, max_delay and max_attempts.
+	Backoff backoff.Policy `yaml:"backoff"`
+	// Schedule is a cron expression such as "0 9 * * MON-FRI" for the escalation rounds.
+	// It replaces Interval and Cadence between rounds, and is matched in the send window
+	// time zone, or the local time zone without one. Interval is still required, as
+	// it spaces the retries of failed rounds without a Backoff.
+	Schedule string `yaml:"schedule,omitempty"`
+	Subject  string `yaml:"subject"`
+	Template string `yaml:"template"`
+	// HTMLTemplate is an optional html/template for email bodies. It can use .Subject, .Text,
+	// .Paragraphs, .Attempt, .Complaint and .Attempts, the send history of the complaint.
+	HTMLTemplate string   `yaml:"html_template,omitempty"`
+	Channels     []string `yaml:"channels"`
 	// Azure Communication Services configuration
 	ACS struct {
 		ConnectionString string `yaml:"connection_string"`
 		Domain           string `yaml:"domain"`
 		FromEmail        string `yaml:"from_email"`
 	} `yaml:"acs"`
+	// EmailTransport selects how email is sent: "acs" (the default) or "smtp"
+	EmailTransport string `yaml:"email_transport,omitempty"`
+	// SMTP server for the "smtp" email transport
+	SMTP struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port,omitempty"`
+		// Security is "starttls" (the default), "tls" for implicit TLS or "none"
+		Security string `yaml:"security,omitempty"`
+		Username string `yaml:"username,omitempty"`
+		Password string `yaml:"password,omitempty"`
+		// Auth is "plain" (the default) or "login"
+		Auth      string `yaml:"auth,omitempty"`
+		FromEmail string `yaml:"from_email,omitempty"`
+	} `yaml:"smtp,omitempty"`
 	// Email configuration
-	Email struct {
-		To      []string `yaml:"to"`
-		CC      []string `yaml:"cc,omitempty"`
-		BCC     []string `yaml:"bcc,omitempty"`
-		ReplyTo string   `yaml:"reply_to,omitempty"`
-	} `yaml:"email"`
+	Email EmailConfig `yaml:"email"`
+	// Tiers is the escalation ladder of every complaint without its own. Without tiers
+	// complaints are sent to the same recipients and channels every round.
+	Tiers []Tier `yaml:"tiers,omitempty"`
+	// FollowUpSubject marks the subject of follow-up emails in a complaint thread:
+	// "re" for a "Re: " prefix, "follow-up" for "[Follow-up #N] " or empty for none
+	FollowUpSubject string `yaml:"follow_up_subject,omitempty"`
+	// SendWindow limits escalation sends to the working hours of the recipients. Sends
+	// planned outside it, retries included, move to the start of the next allowed slot.
+	SendWindow struct {
+		// TimeZone is an IANA time zone such as "Europe/Berlin". It defaults to UTC.
+		TimeZone string `yaml:"time_zone,omitempty"`
+		// Days are the allowed weekdays, such as [mon, tue, wed, thu, fri]. Empty means every day.
+		Days []string `yaml:"days,omitempty"`
+		// Start and End are the allowed hours as "09:00" and "17:00". The end is exclusive.
+		Start string `yaml:"start,omitempty"`
+		End   string `yaml:"end,omitempty"`
+		// Holidays is the path of an iCalendar (.ics) file. No sends happen on the days of its events.
+		Holidays string `yaml:"holidays,omitempty"`
+	} `yaml:"send_window,omitempty"`
+	// AI text generation configuration. Without an endpoint the template is sent unchanged.
+	AI struct {
+		Endpoint string `yaml:"endpoint,omitempty"`
+		Model    string `yaml:"model,omitempty"`
+		APIKey   string `yaml:"api_key,omitempty"`
+		// MaxAttempts is how often generation is retried when the text drifts from the template
+		MaxAttempts int `yaml:"max_attempts,omitempty"`
+	} `yaml:"ai,omitempty"`
+	// Slack incoming webhook for the "slack" channel
+	Slack struct {
+		WebhookURL string `yaml:"webhook_url,omitempty"`
+	} `yaml:"slack,omitempty"`
+	// Telegram bot for the "telegram" channel
+	Telegram struct {
+		BotToken string   `yaml:"bot_token,omitempty"`
+		ChatIDs  []string `yaml:"chat_ids,omitempty"`
+		// APIURL overrides the Bot API endpoint, for example for a local Bot API server
+		APIURL string `yaml:"api_url,omitempty"`
+	} `yaml:"telegram,omitempty"`
+	// Microsoft Teams incoming webhook for the "teams" channel
+	Teams struct {
+		WebhookURL string `yaml:"webhook_url,omitempty"`
+	} `yaml:"teams,omitempty"`
+	// Discord webhook for the "discord" channel
+	Discord struct {
+		WebhookURL string `yaml:"webhook_url,omitempty"`
+	} `yaml:"discord,omitempty"`
+	// SMS through Azure Communication Services for the "sms" channel. Uses the ACS connection string.
+	SMS struct {
+		// From and To are phone numbers in E.164 format, such as +6591234567
+		From string   `yaml:"from,omitempty"`
+		To   []string `yaml:"to,omitempty"`
+		// MaxSegments is the segment budget of one SMS. Longer texts are split into
+		// several messages, or truncated when Truncate is set.
+		MaxSegments int  `yaml:"max_segments,omitempty"`
+		Truncate    bool `yaml:"truncate,omitempty"`
+	} `yaml:"sms,omitempty"`
+	// Webhooks for the "webhook" channel. Requests are signed with the secret.
+	Webhook struct {
+		URLs   []string `yaml:"urls,omitempty"`
+		Secret string   `yaml:"secret,omitempty"`
+	} `yaml:"webhook,omitempty"`
+	// Inbound reply detection. Complaints that receive a reply stop escalating.
+	Inbound struct {
+		// ReplyAddress is a mailbox whose mail reaches the inbound webhook. Complaints without
+		// a reply-to address of their own use it tagged with their id, as replies+<id>@domain.
+		ReplyAddress string `yaml:"reply_address,omitempty"`
+		// Secret enables POST /inbound/email. Mail relays sign their deliveries with it
+		// like outbound webhooks, in the X-Escalator-Signature header.
+		Secret string `yaml:"secret,omitempty"`
+	} `yaml:"inbound,omitempty"`
+	// Storage configuration. Without a path everything is kept in memory.
+	Storage struct {
+		Path string `yaml:"path,omitempty"`
+	} `yaml:"storage,omitempty"`
+	// Complaints to escalate. When empty, the top-level subject, template,
+	// channels and email settings describe a single default complaint.
+	Complaints []ComplaintConfig `yaml:"complaints,omitempty"`
+}
+
+// EmailConfig holds the recipients of an email
+type EmailConfig struct {
+	To      []string `yaml:"to"`
+	CC      []string `yaml:"cc,omitempty"`
+	BCC     []string `yaml:"bcc,omitempty"`
+	ReplyTo string   `yaml:"reply_to,omitempty"`
+}
+
+// ComplaintConfig describes a complaint that is escalated from startup.
+// Empty fields fall back to the top-level configuration.
+type ComplaintConfig struct {
+	ID       string      `yaml:"id"`
+	Subject  string      `yaml:"subject,omitempty"`
+	Template string      `yaml:"template,omitempty"`
+	Channels []string    `yaml:"channels,omitempty"`
+	Email    EmailConfig `yaml:"email,omitempty"`
+	Tiers    []Tier      `yaml:"tiers,omitempty"`
+	Schedule string      `yaml:"schedule,omitempty"`
+}
+
+// Tier is a rung of the escalation ladder. Empty fields fall back to the complaint.
+// A complaint moves up to the next tier after Attempts unanswered sends or once the
+// tier has lasted After, whichever comes first. The last tier is never left.
+type Tier struct {
+	Name     string      `yaml:"name"`
+	Email    EmailConfig `yaml:"email,omitempty"`
+	Channels []string    `yaml:"channels,omitempty"`
+	Template string      `yaml:"template,omitempty"`
+	// Tone asks AI text generation for a tone, such as "firm" or "formal legal"
+	Tone     string        `yaml:"tone,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty"`
+	Attempts int           `yaml:"attempts,omitempty"`
+	After    time.Duration `yaml:"after,omitempty"`
 }
 
 func LoadConfig(path string) (Config, error) {
@@ -39,6 +177,95 @@ func LoadConfig(path string) (Config, error) {
 	if err := decoder.Decode(&cfg); err != nil {
 		return cfg, err
 	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
 
 	return cfg, nil
 }
+
+// Validate checks the cron schedules of the configuration
+func (c Config) Validate() error {
+	scheduled := c.Schedule != ""
+	if c.Schedule != "" {
+		if _, err := cron.Parse(c.Schedule, time.UTC); err != nil {
+			return fmt.Errorf("schedule: %w", err)
+		}
+	}
+	for _, cc := range c.Complaints {
+		if cc.Schedule == "" {
+			continue
+		}
+		scheduled = true
+		if _, err := cron.Parse(cc.Schedule, time.UTC); err != nil {
+			return fmt.Errorf("complaint %q: schedule: %w", cc.ID, err)
+		}
+	}
+	if scheduled && c.Interval <= 0 {
+		return fmt.Errorf("interval is required with a schedule: it spaces the retries of failed rounds")
+	}
+	return nil
+}
+
+// CadencePolicy returns the policy of the interval between escalation rounds
+func (c Config) CadencePolicy() backoff.Policy {
+	if c.Cadence.IsZero() {
+		return backoff.Constant(c.Interval)
+	}
+	cadence := c.Cadence
+	if cadence.Initial == 0 {
+		cadence.Initial = c.Interval
+	}
+	return cadence
+}
+
+// FromEmail returns the sender address of the configured email transport
+func (c Config) FromEmail() string {
+	if c.EmailTransport == "smtp" && c.SMTP.FromEmail != "" {
+		return c.SMTP.FromEmail
+	}
+	return c.ACS.FromEmail
+}
+
+// ComplaintConfigs returns the configured complaints with empty fields filled in
+// from the top-level configuration
+func (c Config) ComplaintConfigs() []ComplaintConfig {
+	if len(c.Complaints) == 0 {
+		if c.Template == "" {
+			return nil
+		}
+		return []ComplaintConfig{{
+			ID:       "default",
+			Subject:  c.Subject,
+			Template: c.Template,
+			Channels: c.Channels,
+			Email:    c.Email,
+			Tiers:    c.Tiers,
+			Schedule: c.Schedule,
+		}}
+	}
+
+	result := make([]ComplaintConfig, len(c.Complaints))
+	for i, cc := range c.Complaints {
+		if cc.Subject == "" {
+			cc.Subject = c.Subject
+		}
+		if cc.Template == "" {
+			cc.Template = c.Template
+		}
+		if len(cc.Channels) == 0 {
+			cc.Channels = c.Channels
+		}
+		if len(cc.Email.To) == 0 {
+			cc.Email = c.Email
+		}
+		if len(cc.Tiers) == 0 {
+			cc.Tiers = c.Tiers
+		}
+		if cc.Schedule == "" {
+			cc.Schedule = c.Schedule
+		}
+		result[i] = cc
+	}
+	return result
+}
@@ -1,9 +1,15 @@
 package config
 
 import (
+	"complaint-escalator/internal/backoff"
 	"complaint-escalator/pkg/testutils"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -21,10 +27,16 @@ func TestLoadConfig(t *testing.T) {
 
 	// Test backoff configuration
 	expectedBackoff := 2 * time.Minute
-	if cfg.Backoff != expectedBackoff {
+	if cfg.Backoff != backoff.Constant(expectedBackoff) {
 		t.Errorf("Expected backoff %v, got %v", expectedBackoff, cfg.Backoff)
 	}
 
+	// Test subject configuration
+	expectedSubject := "Test complaint subject"
+	if cfg.Subject != expectedSubject {
+		t.Errorf("Expected subject %s, got %s", expectedSubject, cfg.Subject)
+	}
+
 	// Test template configuration
 	expectedTemplate := "Test complaint template for automated testing."
 	if cfg.Template != expectedTemplate {
@@ -67,3 +79,171 @@ func TestLoadConfig(t *testing.T) {
 		t.Error("Email 'reply_to' not loaded correctly")
 	}
 }
+
+func TestComplaintConfigs(t *testing.T) {
+	cfg, err := LoadConfig(testutils.GetTestConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load test config: %v", err)
+	}
+
+	// Without a complaints section the top-level settings form the default complaint
+	complaints := cfg.ComplaintConfigs()
+	if len(complaints) != 1 {
+		t.Fatalf("Expected 1 complaint, got %d", len(complaints))
+	}
+	if complaints[0].ID != "default" || complaints[0].Template != cfg.Template || complaints[0].Subject != cfg.Subject {
+		t.Errorf("Default complaint not derived from top-level config: %+v", complaints[0])
+	}
+
+	// Explicit complaints inherit missing fields
+	cfg.Complaints = []ComplaintConfig{
+		{ID: "refund", Template: "Please refund order #42."},
+		{ID: "isp", Subject: "Outage", Channels: []string{"notification"}, Email: EmailConfig{To: []string{"isp@example.com"}}},
+	}
+	complaints = cfg.ComplaintConfigs()
+	if len(complaints) != 2 {
+		t.Fatalf("Expected 2 complaints, got %d", len(complaints))
+	}
+	if complaints[0].Subject != cfg.Subject || len(complaints[0].Channels) != 2 || complaints[0].Email.To[0] != "test@example.com" {
+		t.Errorf("Complaint did not inherit top-level settings: %+v", complaints[0])
+	}
+	if complaints[1].Template != cfg.Template || complaints[1].Channels[0] != "notification" || complaints[1].Email.To[0] != "isp@example.com" {
+		t.Errorf("Complaint overrides not kept: %+v", complaints[1])
+	}
+}
+
+func TestTiers(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+tiers:
+  - name: support
+    attempts: 3
+  - name: manager
+    email:
+      to: ["manager@shop.example"]
+    tone: firm
+    interval: 12h
+    after: 72h
+  - name: legal
+    channels: [email]
+    template: "Formal notice about order #1234."
+complaints:
+  - id: order
+  - id: refund
+    tiers:
+      - name: support
+`), &cfg)
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	if len(cfg.Tiers) != 3 {
+		t.Fatalf("Expected 3 tiers, got %d", len(cfg.Tiers))
+	}
+	manager := cfg.Tiers[1]
+	if manager.Interval != 12*time.Hour || manager.After != 72*time.Hour || manager.Tone != "firm" || manager.Email.To[0] != "manager@shop.example" {
+		t.Errorf("Unexpected manager tier: %+v", manager)
+	}
+
+	// Complaints without their own ladder use the top-level one
+	complaints := cfg.ComplaintConfigs()
+	if len(complaints[0].Tiers) != 3 || len(complaints[1].Tiers) != 1 {
+		t.Errorf("Expected 3 and 1 tiers, got %d and %d", len(complaints[0].Tiers), len(complaints[1].Tiers))
+	}
+}
+
+func TestBackoffPolicies(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+interval: 24h
+cadence:
+  strategy: exponential
+  multiplier: 1.5
+  max_delay: 168h
+backoff:
+  strategy: exponential
+  initial: 1m
+  jitter: full
+  max_delay: 30m
+  max_attempts: 5
+`), &cfg)
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	expectedBackoff := backoff.Policy{Strategy: backoff.StrategyExponential, Initial: time.Minute, Jitter: backoff.JitterFull, MaxDelay: 30 * time.Minute, MaxAttempts: 5}
+	if cfg.Backoff != expectedBackoff {
+		t.Errorf("Unexpected backoff: got %+v want %+v", cfg.Backoff, expectedBackoff)
+	}
+
+	// The cadence starts at the interval
+	cadence := cfg.CadencePolicy()
+	if cadence.Initial != 24*time.Hour || cadence.Base(2) != 36*time.Hour || cadence.Base(10) != 168*time.Hour {
+		t.Errorf("Unexpected cadence: %+v", cadence)
+	}
+
+	// Without a cadence the interval is constant
+	cfg.Cadence = backoff.Policy{}
+	if cfg.CadencePolicy() != backoff.Constant(24*time.Hour) {
+		t.Errorf("Expected a constant cadence, got %+v", cfg.CadencePolicy())
+	}
+}
+
+func TestSchedules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+
+	write(`
+interval: 24h
+schedule: "0 9 * * MON-FRI"
+template: "Please refund order #42."
+complaints:
+  - id: refund
+  - id: isp
+    schedule: "CRON_TZ=Europe/Berlin 30 8 * * MON"
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Complaints without a schedule inherit the top-level one
+	complaints := cfg.ComplaintConfigs()
+	if complaints[0].Schedule != "0 9 * * MON-FRI" || complaints[1].Schedule != "CRON_TZ=Europe/Berlin 30 8 * * MON" {
+		t.Errorf("Unexpected schedules: %q and %q", complaints[0].Schedule, complaints[1].Schedule)
+	}
+
+	// Invalid expressions fail at load time
+	write(`
+interval: 24h
+schedule: "0 9 * * MON-FRI"
+complaints:
+  - id: isp
+    schedule: "0 25 * * *"
+`)
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), `complaint "isp"`) {
+		t.Errorf("Expected schedule error for complaint isp, got %v", err)
+	}
+
+	write(`
+interval: 24h
+schedule: "every weekday"
+`)
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected error for an invalid top-level schedule")
+	}
+
+	// A schedule does not replace the interval, which spaces retries
+	write(`
+complaints:
+  - id: isp
+    schedule: "0 9 * * MON-FRI"
+`)
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "interval is required") {
+		t.Errorf("Expected interval error for a schedule without interval, got %v", err)
+	}
+}
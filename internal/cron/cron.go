@@ -0,0 +1,257 @@
+package cron
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchYears limits how far ahead Next looks for a matching time
+const searchYears = 5
+
+// field describes one of the five fields of an expression
+type field struct {
+	name     string
+	min, max int
+	// names maps names such as "JAN" or "MON" to values
+	names map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	dayField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	// Sunday is both 0 and 7
+	weekdayField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+// descriptors are the shorthands for common expressions
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// daysInMonth is the most days each month can have
+var daysInMonth = [13]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// Schedule is a parsed cron expression. Each field is a bit set of the values it matches.
+type Schedule struct {
+	expr                                   string
+	minutes, hours, days, months, weekdays uint64
+	location                               *time.Location
+	// anyDay and anyWeekday record a "*" day of month or day of week. When both days are
+	// restricted, a time matches if either of them does, as in classic cron.
+	anyDay, anyWeekday bool
+}
+
+// Parse parses a five field cron expression such as "0 9 * * MON-FRI": minute, hour, day
+// of month, month and day of week. Fields take "*", values, names, ranges ("1-5"), steps
+// ("*/15", "0-30/10") and lists ("MON,WED,FRI"). The shorthands @yearly, @monthly,
+// @weekly, @daily and @hourly are accepted too.
+//
+// Times are matched in location, unless the expression starts with a time zone such as
+// "CRON_TZ=Europe/Berlin 0 9 * * MON-FRI".
+func Parse(expr string, location *time.Location) (*Schedule, error) {
+	if location == nil {
+		location = time.Local
+	}
+
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		zone, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(zone, "=")
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q: %w", name, err)
+		}
+		location, spec = loc, strings.TrimSpace(rest)
+	}
+	if descriptor, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+
+	s := &Schedule{expr: expr, location: location}
+	var err error
+	if s.minutes, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hours, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if s.days, err = parseField(fields[2], dayField); err != nil {
+		return nil, err
+	}
+	if s.months, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if s.weekdays, err = parseField(fields[4], weekdayField); err != nil {
+		return nil, err
+	}
+	// Fold 7 into Sunday
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays = s.weekdays&^(1<<7) | 1
+	}
+	s.anyDay = strings.HasPrefix(fields[2], "*")
+	s.anyWeekday = strings.HasPrefix(fields[4], "*")
+
+	if !s.possible() {
+		return nil, fmt.Errorf("cron expression %q never fires", expr)
+	}
+	return s, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Location returns the time zone the schedule is matched in
+func (s *Schedule) Location() *time.Location {
+	return s.location
+}
+
+// Next returns the first matching time after t, in the location of the schedule. It
+// returns the zero time when nothing matches within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + searchYears
+
+	// Skip ahead one unit of the first field that does not match. Days and months move
+	// to midnight, hours and minutes by elapsed time so that daylight saving changes
+	// never move backwards.
+	for t.Year() <= limit {
+		switch {
+		case s.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+		case s.hours&(1<<uint(t.Hour())) == 0:
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case s.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches the day of month and day of week
+func (s *Schedule) dayMatches(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// possible reports whether some month has a matching day, which rules out expressions
+// such as "0 0 30 2 *"
+func (s *Schedule) possible() bool {
+	if !s.anyWeekday && !s.anyDay {
+		return true
+	}
+	for month := 1; month <= 12; month++ {
+		if s.months&(1<<uint(month)) == 0 {
+			continue
+		}
+		// The lowest matching day must exist in the month
+		if bits.TrailingZeros64(s.days) <= daysInMonth[month] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseField parses a comma separated list of values, ranges and steps into a bit set
+func parseField(spec string, f field) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(spec, ",") {
+		bitsOfPart, err := parsePart(part, f)
+		if err != nil {
+			return 0, fmt.Errorf("%s %q: %w", f.name, spec, err)
+		}
+		set |= bitsOfPart
+	}
+	return set, nil
+}
+
+// parsePart parses "*", "5", "MON", "1-5" or any of them followed by a step such as "/2"
+func parsePart(part string, f field) (uint64, error) {
+	rangeSpec, stepSpec, hasStep := strings.Cut(part, "/")
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepSpec)
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q", stepSpec)
+		}
+	}
+
+	low, high := f.min, f.max
+	switch {
+	case rangeSpec == "*":
+	case strings.Contains(rangeSpec, "-"):
+		from, to, _ := strings.Cut(rangeSpec, "-")
+		var err error
+		if low, err = parseValue(from, f); err != nil {
+			return 0, err
+		}
+		if high, err = parseValue(to, f); err != nil {
+			return 0, err
+		}
+		if low > high {
+			return 0, fmt.Errorf("range %q runs backwards", rangeSpec)
+		}
+	default:
+		value, err := parseValue(rangeSpec, f)
+		if err != nil {
+			return 0, err
+		}
+		// "5/15" runs from 5 to the end of the field
+		low = value
+		if !hasStep {
+			high = value
+		}
+	}
+
+	var set uint64
+	for v := low; v <= high; v += step {
+		set |= 1 << uint(v)
+	}
+	return set, nil
+}
+
+// parseValue parses a number or a name within the bounds of the field
+func parseValue(s string, f field) (int, error) {
+	if value, ok := f.names[strings.ToUpper(s)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("value %d is outside %d-%d", value, f.min, f.max)
+	}
+	return value, nil
+}
@@ -0,0 +1,101 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		from     time.Time
+		expected time.Time
+	}{
+		// Wednesday 8 January 2025
+		{"weekday morning", "0 9 * * MON-FRI", time.Date(2025, 1, 8, 8, 15, 0, 0, time.UTC), time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC)},
+		{"strictly after", "0 9 * * MON-FRI", time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC), time.Date(2025, 1, 9, 9, 0, 0, 0, time.UTC)},
+		{"over the weekend", "0 9 * * MON-FRI", time.Date(2025, 1, 10, 9, 30, 0, 0, time.UTC), time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)},
+		{"steps", "*/15 * * * *", time.Date(2025, 1, 8, 8, 16, 30, 0, time.UTC), time.Date(2025, 1, 8, 8, 30, 0, 0, time.UTC)},
+		{"range with step", "0 8-18/4 * * *", time.Date(2025, 1, 8, 12, 1, 0, 0, time.UTC), time.Date(2025, 1, 8, 16, 0, 0, 0, time.UTC)},
+		{"lists", "30 10 * * mon,wed,fri", time.Date(2025, 1, 8, 11, 0, 0, 0, time.UTC), time.Date(2025, 1, 10, 10, 30, 0, 0, time.UTC)},
+		{"sunday as 7", "0 12 * * 7", time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 12, 12, 0, 0, 0, time.UTC)},
+		{"month names", "0 0 1 JAN,JUL *", time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC), time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either the 1st of the month or a Monday
+		{"day of month or week", "0 9 1 * MON", time.Date(2025, 1, 28, 10, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"descriptor", "@daily", time.Date(2025, 1, 8, 8, 0, 0, 0, time.UTC), time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC)},
+		{"time zone prefix", "CRON_TZ=Europe/Berlin 0 9 * * MON-FRI", time.Date(2025, 1, 8, 8, 15, 0, 0, time.UTC), time.Date(2025, 1, 9, 8, 0, 0, 0, time.UTC)},
+		// Clocks go forward on Sunday 30 March 2025 and back on Sunday 26 October 2025
+		{"spring forward", "CRON_TZ=Europe/Berlin 0 9 * * *", time.Date(2025, 3, 29, 9, 0, 0, 0, berlin), time.Date(2025, 3, 30, 9, 0, 0, 0, berlin)},
+		{"fall back", "CRON_TZ=Europe/Berlin 0 9 * * *", time.Date(2025, 10, 25, 9, 0, 0, 0, berlin), time.Date(2025, 10, 26, 9, 0, 0, 0, berlin)},
+		{"hourly across fall back", "CRON_TZ=Europe/Berlin 0 * * * *", time.Date(2025, 10, 26, 2, 0, 0, 0, time.UTC), time.Date(2025, 10, 26, 3, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr, time.UTC)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.expr, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.expected) {
+				t.Errorf("Next(%v): got %v want %v", tt.from, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScheduleLocation(t *testing.T) {
+	singapore, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+
+	// Every weekday at 9am in Singapore, which is 01:00 UTC
+	schedule, err := Parse("0 9 * * MON-FRI", singapore)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	from := time.Date(2025, 1, 8, 2, 0, 0, 0, time.UTC)
+	expected := time.Date(2025, 1, 9, 1, 0, 0, 0, time.UTC)
+	if got := schedule.Next(from); !got.Equal(expected) {
+		t.Errorf("Next(%v): got %v want %v", from, got, expected)
+	}
+	if schedule.Location() != singapore {
+		t.Errorf("Expected location %v, got %v", singapore, schedule.Location())
+	}
+	if schedule.String() != "0 9 * * MON-FRI" {
+		t.Errorf("Expected the original expression, got %q", schedule.String())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"0 9 * *",
+		"0 9 * * MON-FRI *",
+		"60 9 * * *",
+		"0 24 * * *",
+		"0 9 0 * *",
+		"0 9 * 13 *",
+		"0 9 * * 8",
+		"0 9 * * FUNDAY",
+		"0 9 * * FRI-MON",
+		"*/0 * * * *",
+		"a * * * *",
+		"0 0 30 2 *",
+		"CRON_TZ=Mars/Olympus 0 9 * * *",
+		"@fortnightly",
+	}
+
+	for _, expr := range tests {
+		if _, err := Parse(expr, time.UTC); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
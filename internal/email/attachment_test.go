@@ -0,0 +1,163 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// testReceipt is a small attachment
+var testReceipt = Attachment{
+	Name:        "receipt-1234.pdf",
+	ContentType: "application/pdf",
+	Content:     []byte("%PDF-1.4 receipt for order #1234"),
+}
+
+func TestValidateAttachments(t *testing.T) {
+	tests := []struct {
+		name        string
+		attachments []Attachment
+		valid       bool
+	}{
+		{"none", nil, true},
+		{"valid", []Attachment{testReceipt}, true},
+		{"missing name", []Attachment{{ContentType: "image/png", Content: []byte{1}}}, false},
+		{"header injection in name", []Attachment{{Name: "a\r\nBcc: x@example.com", ContentType: "image/png", Content: []byte{1}}}, false},
+		{"path in name", []Attachment{{Name: "../etc/passwd", ContentType: "text/plain", Content: []byte{1}}}, false},
+		{"missing content type", []Attachment{{Name: "a.png", Content: []byte{1}}}, false},
+		{"invalid content type", []Attachment{{Name: "a.png", ContentType: "image png;", Content: []byte{1}}}, false},
+		{"empty content", []Attachment{{Name: "a.png", ContentType: "image/png"}}, false},
+		{"at the size limit", []Attachment{{Name: "a.bin", ContentType: "application/octet-stream", Content: make([]byte, MaxAttachmentsSize/4*3)}}, true},
+		{"over the size limit in total", []Attachment{
+			{Name: "a.bin", ContentType: "application/octet-stream", Content: make([]byte, MaxAttachmentsSize/4*3)},
+			{Name: "b.bin", ContentType: "application/octet-stream", Content: []byte{1}},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAttachments(tt.attachments)
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid attachments: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestSendEmailWithAttachments(t *testing.T) {
+	var received azureEmailRequest
+	server := newFakeACSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `{"id":"op-1","status":"Running"}`)
+	})
+
+	client, err := NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+
+	msg := EmailMessage{
+		From:        "test@test-domain.dev",
+		To:          []string{"recipient@example.com"},
+		Subject:     "Test Subject",
+		Body:        "Test Body",
+		Attachments: []Attachment{testReceipt},
+	}
+	if _, err := client.SendEmail(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	expected := azureEmailAttachment{
+		Name:            "receipt-1234.pdf",
+		ContentType:     "application/pdf",
+		ContentInBase64: "JVBERi0xLjQgcmVjZWlwdCBmb3Igb3JkZXIgIzEyMzQ=",
+	}
+	if len(received.Attachments) != 1 || received.Attachments[0] != expected {
+		t.Errorf("Expected attachments %+v, got %+v", expected, received.Attachments)
+	}
+
+	// Invalid attachments are rejected before sending
+	msg.Attachments = []Attachment{{Name: "empty.txt", ContentType: "text/plain"}}
+	if _, err := client.SendEmail(context.Background(), msg); err == nil {
+		t.Error("Expected error for empty attachment")
+	}
+}
+
+func TestSMTPClientAttachments(t *testing.T) {
+	server, pool := newFakeSMTPServer(t, false)
+	client := newTestSMTPClient(t, server, pool, SMTPConfig{})
+
+	msg := testSMTPMessage
+	msg.HTMLBody = "<p>Where is my order?</p>"
+	msg.Attachments = []Attachment{testReceipt, {Name: "photo.png", ContentType: "image/png", Content: bytes.Repeat([]byte{0x89}, 200)}}
+	if _, err := client.SendEmail(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(server.received()[0].Data))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Expected multipart/mixed, got %q (%v)", parsed.Header.Get("Content-Type"), err)
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+
+	// The text comes first, with its HTML alternative
+	part, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(part.Header.Get("Content-Type"), "multipart/alternative") {
+		t.Errorf("Expected the text as first part, got %q", part.Header.Get("Content-Type"))
+	}
+
+	for _, expected := range msg.Attachments {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Expected attachment %s: %v", expected.Name, err)
+		}
+		if part.FileName() != expected.Name || part.Header.Get("Content-Type") != expected.ContentType {
+			t.Errorf("Unexpected attachment %q of type %q", part.FileName(), part.Header.Get("Content-Type"))
+		}
+		encoded, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\n") {
+			if len(strings.TrimSuffix(line, "\r")) > 76 {
+				t.Errorf("Base64 line of %d characters", len(strings.TrimSuffix(line, "\r")))
+			}
+		}
+		content, err := io.ReadAll(base64Decoder(encoded))
+		if err != nil || !bytes.Equal(content, expected.Content) {
+			t.Errorf("Attachment %s content does not round-trip (%v)", expected.Name, err)
+		}
+	}
+
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("Expected no further parts, got %v", err)
+	}
+}
+
+// base64Decoder decodes base64 split over several lines
+func base64Decoder(encoded []byte) io.Reader {
+	return base64.NewDecoder(base64.StdEncoding, strings.NewReader(strings.NewReplacer("\r", "", "\n", "").Replace(string(encoded))))
+}
@@ -3,21 +3,27 @@ package email
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"strings"
 	"time"
 )
 
+// apiVersion is the version of the Azure Communication Services email API
+const apiVersion = "2023-03-31"
+
 // EmailClient represents an Azure Communication Services email client
 type EmailClient struct {
 	connectionString string
 	httpClient       *http.Client
 	endpoint         string
 	accessKey        string
+	signer           *Signer
 }
 
 // EmailMessage represents an email message to be sent
@@ -26,25 +32,63 @@ type EmailMessage struct {
 	To      []string `json:"to"`
 	Subject string   `json:"subject"`
 	Body    string   `json:"body"`
+	// HTMLBody is an optional HTML version of Body. When Body is empty it is derived from HTMLBody.
+	HTMLBody string `json:"htmlBody,omitempty"`
 	// Optional fields
 	CC      []string `json:"cc,omitempty"`
 	BCC     []string `json:"bcc,omitempty"`
 	ReplyTo string   `json:"replyTo,omitempty"`
+	// Attachments are sent along with the message, up to MaxAttachmentsSize in total
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// MessageID is the Message-ID of the message, such as a thread root from ThreadRootID.
+	// The SMTP transport generates one when it is empty. ACS always assigns its own and
+	// sends MessageID in References instead, so replies still name the thread root.
+	MessageID string `json:"messageId,omitempty"`
+	// InReplyTo and References link the message to earlier messages of its thread
+	InReplyTo  string   `json:"inReplyTo,omitempty"`
+	References []string `json:"references,omitempty"`
+	// RequestID makes an ACS send repeatable: ACS sends a message only once however often
+	// a request with the same id and FirstSent is repeated. RetrySender sets both.
+	RequestID string    `json:"-"`
+	FirstSent time.Time `json:"-"`
+}
+
+// MaxAttachmentsSize is the maximum base64 encoded size of all attachments of a message.
+// ACS rejects messages larger than 10 MB.
+const MaxAttachmentsSize = 10 << 20
+
+// Attachment represents a file attached to an email message
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	// Content is the raw file content. It is base64 encoded in JSON.
+	Content []byte `json:"content"`
 }
 
 // Azure Email API Request structure
 type azureEmailRequest struct {
-	SenderAddress string `json:"senderAddress"`
-	Content       struct {
-		Subject   string `json:"subject"`
-		PlainText string `json:"plainText"`
-	} `json:"content"`
-	Recipients struct {
+	SenderAddress string            `json:"senderAddress"`
+	Content       azureEmailContent `json:"content"`
+	Recipients    struct {
 		To  []azureEmailAddress `json:"to"`
 		CC  []azureEmailAddress `json:"cc,omitempty"`
 		BCC []azureEmailAddress `json:"bcc,omitempty"`
 	} `json:"recipients"`
-	ReplyTo *azureEmailAddress `json:"replyTo,omitempty"`
+	ReplyTo     *azureEmailAddress     `json:"replyTo,omitempty"`
+	Attachments []azureEmailAttachment `json:"attachments,omitempty"`
+	Headers     map[string]string      `json:"headers,omitempty"`
+}
+
+type azureEmailAttachment struct {
+	Name            string `json:"name"`
+	ContentType     string `json:"contentType"`
+	ContentInBase64 string `json:"contentInBase64"`
+}
+
+type azureEmailContent struct {
+	Subject   string `json:"subject"`
+	PlainText string `json:"plainText"`
+	HTML      string `json:"html,omitempty"`
 }
 
 type azureEmailAddress struct {
@@ -58,7 +102,7 @@ func NewEmailClient(connectionString string) (*EmailClient, error) {
 	}
 
 	// Parse connection string
-	endpoint, accessKey, err := parseConnectionString(connectionString)
+	endpoint, accessKey, err := ParseConnectionString(connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse connection string: %w", err)
 	}
@@ -68,26 +112,26 @@ func NewEmailClient(connectionString string) (*EmailClient, error) {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		endpoint:  endpoint,
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
 		accessKey: accessKey,
+		signer:    NewSigner(accessKey),
 	}, nil
 }
 
-// SendEmail sends an email using the Azure Communication Services REST API
-func (ec *EmailClient) SendEmail(ctx context.Context, msg EmailMessage) error {
-	if err := ec.validateMessage(msg); err != nil {
-		return fmt.Errorf("invalid email message: %w", err)
+// SendEmail sends an email using the Azure Communication Services REST API.
+// It returns the id of the long-running send operation, which can be followed with GetOperation.
+func (ec *EmailClient) SendEmail(ctx context.Context, msg EmailMessage) (string, error) {
+	if err := validateMessage(msg); err != nil {
+		return "", fmt.Errorf("invalid email message: %w", err)
 	}
 
 	// Convert to Azure API format
 	azureReq := azureEmailRequest{
 		SenderAddress: msg.From,
-		Content: struct {
-			Subject   string `json:"subject"`
-			PlainText string `json:"plainText"`
-		}{
+		Content: azureEmailContent{
 			Subject:   msg.Subject,
-			PlainText: msg.Body,
+			PlainText: msg.PlainText(),
+			HTML:      msg.HTMLBody,
 		},
 		Recipients: struct {
 			To  []azureEmailAddress `json:"to"`
@@ -113,47 +157,93 @@ func (ec *EmailClient) SendEmail(ctx context.Context, msg EmailMessage) error {
 		azureReq.ReplyTo = &azureEmailAddress{Email: msg.ReplyTo}
 	}
 
+	// Add attachments if provided
+	for _, a := range msg.Attachments {
+		azureReq.Attachments = append(azureReq.Attachments, azureEmailAttachment{
+			Name:            a.Name,
+			ContentType:     a.ContentType,
+			ContentInBase64: base64.StdEncoding.EncodeToString(a.Content),
+		})
+	}
+
+	// Add thread headers if provided
+	if headers := msg.acsThreadHeaders(); len(headers) > 0 {
+		azureReq.Headers = headers
+	}
+
 	// Convert to JSON
 	jsonData, err := json.Marshal(azureReq)
 	if err != nil {
-		return fmt.Errorf("failed to marshal email request: %w", err)
+		return "", fmt.Errorf("failed to marshal email request: %w", err)
 	}
 
 	// Create HTTP request
-	url := fmt.Sprintf("%s/emails:send?api-version=2023-03-31", ec.endpoint)
+	url := fmt.Sprintf("%s/emails:send?api-version=%s", ec.endpoint, apiVersion)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	// Add headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("HMAC-SHA256 %s", ec.accessKey))
+	if msg.RequestID != "" {
+		firstSent := msg.FirstSent
+		if firstSent.IsZero() {
+			firstSent = time.Now()
+		}
+		req.Header.Set("repeatability-request-id", msg.RequestID)
+		req.Header.Set("repeatability-first-sent", firstSent.UTC().Format(http.TimeFormat))
+	}
+	if err := ec.signer.Sign(req, jsonData); err != nil {
+		return "", fmt.Errorf("failed to sign HTTP request: %w", err)
+	}
 
 	// Send request
 	resp, err := ec.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send HTTP request: %w", err)
+		return "", fmt.Errorf("failed to send HTTP request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Check response status
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("email send failed with status %d: %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("email send failed: %w", parseAPIError(resp, body))
 	}
 
-	log.Printf("Email sent successfully. Status: %d", resp.StatusCode)
-	return nil
+	// The operation id is in the body and at the end of the Operation-Location path
+	var op Operation
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &op); err != nil {
+			return "", fmt.Errorf("failed to decode response body: %w", err)
+		}
+	}
+	if op.ID == "" {
+		op.ID = operationIDFromLocation(resp.Header.Get("Operation-Location"))
+	}
+	if op.ID == "" {
+		return "", fmt.Errorf("email send response did not contain an operation id")
+	}
+
+	log.Printf("Email accepted. Status: %d, operation: %s", resp.StatusCode, op.ID)
+	return op.ID, nil
+}
+
+// PlainText returns the plaintext body, derived from the HTML body when there is none
+func (msg EmailMessage) PlainText() string {
+	if msg.Body == "" && msg.HTMLBody != "" {
+		return HTMLToText(msg.HTMLBody)
+	}
+	return msg.Body
 }
 
 // validateMessage validates the email message
-func (ec *EmailClient) validateMessage(msg EmailMessage) error {
+func validateMessage(msg EmailMessage) error {
 	if msg.From == "" {
 		return fmt.Errorf("sender address is required")
 	}
@@ -163,14 +253,44 @@ func (ec *EmailClient) validateMessage(msg EmailMessage) error {
 	if msg.Subject == "" {
 		return fmt.Errorf("subject is required")
 	}
-	if msg.Body == "" {
+	if msg.Body == "" && msg.HTMLBody == "" {
 		return fmt.Errorf("body is required")
 	}
+	if err := validateThread(msg); err != nil {
+		return err
+	}
+	return validateAttachments(msg.Attachments)
+}
+
+// validateAttachments validates the attachments and their total size
+func validateAttachments(attachments []Attachment) error {
+	total := 0
+	for i, a := range attachments {
+		if a.Name == "" {
+			return fmt.Errorf("attachment %d: name is required", i+1)
+		}
+		if strings.ContainsAny(a.Name, "\r\n\"/\\") {
+			return fmt.Errorf("attachment %q: name contains invalid characters", a.Name)
+		}
+		if a.ContentType == "" {
+			return fmt.Errorf("attachment %q: content type is required", a.Name)
+		}
+		if _, _, err := mime.ParseMediaType(a.ContentType); err != nil {
+			return fmt.Errorf("attachment %q: invalid content type %q", a.Name, a.ContentType)
+		}
+		if len(a.Content) == 0 {
+			return fmt.Errorf("attachment %q: content is empty", a.Name)
+		}
+		total += base64.StdEncoding.EncodedLen(len(a.Content))
+	}
+	if total > MaxAttachmentsSize {
+		return fmt.Errorf("attachments are %d bytes encoded, more than the maximum of %d", total, MaxAttachmentsSize)
+	}
 	return nil
 }
 
-// parseConnectionString parses the Azure Communication Services connection string
-func parseConnectionString(connStr string) (endpoint, accessKey string, err error) {
+// ParseConnectionString parses the Azure Communication Services connection string into its endpoint and access key
+func ParseConnectionString(connStr string) (endpoint, accessKey string, err error) {
 	parts := strings.Split(connStr, ";")
 	for _, part := range parts {
 		if strings.HasPrefix(part, "endpoint=") {
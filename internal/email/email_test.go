@@ -53,7 +53,7 @@ func TestEmailMessageValidation(t *testing.T) {
 
 	// Test validation (this should not error)
 	ctx := context.Background()
-	err = emailClient.SendEmail(ctx, validMsg)
+	_, err = emailClient.SendEmail(ctx, validMsg)
 	// Note: This will fail due to invalid test credentials, but validation should pass
 	// We're testing the validation logic, not the actual sending
 	if err == nil {
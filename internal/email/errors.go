@@ -0,0 +1,202 @@
+package email
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorKind classifies a failed Azure Communication Services request
+type ErrorKind string
+
+const (
+	// ErrorAuth means the access key or its signature was rejected (401, 403)
+	ErrorAuth ErrorKind = "auth"
+	// ErrorInvalidRecipient means ACS rejected one of the recipient addresses
+	ErrorInvalidRecipient ErrorKind = "invalid_recipient"
+	// ErrorInvalidRequest means ACS rejected the request for another reason
+	ErrorInvalidRequest ErrorKind = "invalid_request"
+	// ErrorThrottled means the request was rate limited (429)
+	ErrorThrottled ErrorKind = "throttled"
+	// ErrorTransient means ACS failed on its side or timed out (408, 5xx)
+	ErrorTransient ErrorKind = "transient"
+)
+
+// Sentinel errors to check the kind of an APIError with errors.Is
+var (
+	ErrAuth             = errors.New("email authentication failed")
+	ErrInvalidRecipient = errors.New("invalid email recipient")
+	ErrInvalidRequest   = errors.New("invalid email request")
+	ErrThrottled        = errors.New("email sending throttled")
+	ErrTransient        = errors.New("transient email service failure")
+)
+
+// kindErrors maps each kind to its sentinel error
+var kindErrors = map[ErrorKind]error{
+	ErrorAuth:             ErrAuth,
+	ErrorInvalidRecipient: ErrInvalidRecipient,
+	ErrorInvalidRequest:   ErrInvalidRequest,
+	ErrorThrottled:        ErrThrottled,
+	ErrorTransient:        ErrTransient,
+}
+
+// APIError is a failed response from Azure Communication Services
+type APIError struct {
+	StatusCode int
+	Kind       ErrorKind
+	// Code, Message and Target come from the ACS error body. Code is the most specific
+	// code found, which may come from the error details.
+	Code    string
+	Message string
+	Target  string
+	// RetryAfter is the wait ACS asked for, or zero when it did not say
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	s := fmt.Sprintf("email service returned status %d (%s)", e.StatusCode, e.Kind)
+	if e.Code != "" {
+		s += ": " + e.Code
+	}
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	if e.RetryAfter > 0 {
+		s += fmt.Sprintf(", retry after %v", e.RetryAfter)
+	}
+	return s
+}
+
+// Is reports whether target is the sentinel error of the kind of e
+func (e *APIError) Is(target error) bool {
+	return kindErrors[e.Kind] == target
+}
+
+// Temporary reports whether the same request may succeed later
+func (e *APIError) Temporary() bool {
+	return e.Kind == ErrorThrottled || e.Kind == ErrorTransient
+}
+
+// azureErrorResponse is the error body of Azure REST APIs
+type azureErrorResponse struct {
+	Error azureError `json:"error"`
+}
+
+type azureError struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Target  string       `json:"target"`
+	Details []azureError `json:"details"`
+	// InnerError holds a more specific code
+	InnerError *struct {
+		Code string `json:"code"`
+	} `json:"innererror"`
+}
+
+// parseAPIError creates the typed error of a failed ACS response. Bodies that are not
+// ACS error JSON become the message.
+func parseAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: retryAfter(resp.Header),
+	}
+
+	var parsed azureErrorResponse
+	if err := json.Unmarshal(body, &parsed); err == nil && (parsed.Error.Code != "" || parsed.Error.Message != "") {
+		apiErr.Code = mostSpecificCode(parsed.Error)
+		apiErr.Message = parsed.Error.Message
+		apiErr.Target = parsed.Error.Target
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	apiErr.Kind = classify(resp.StatusCode, parsed.Error)
+	return apiErr
+}
+
+// mostSpecificCode returns the code of the first detail or inner error, or else the code
+func mostSpecificCode(e azureError) string {
+	if e.InnerError != nil && e.InnerError.Code != "" {
+		return e.InnerError.Code
+	}
+	for _, detail := range e.Details {
+		if detail.Code != "" {
+			return detail.Code
+		}
+	}
+	return e.Code
+}
+
+// classify determines the kind of a failed response. ACS reports bad recipients as a
+// 400 whose code, target or details mention a recipient or an address.
+func classify(status int, e azureError) ErrorKind {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorAuth
+	case status == http.StatusTooManyRequests:
+		return ErrorThrottled
+	case status == http.StatusRequestTimeout || status >= 500:
+		return ErrorTransient
+	case mentionsRecipient(e):
+		return ErrorInvalidRecipient
+	default:
+		return ErrorInvalidRequest
+	}
+}
+
+// mentionsRecipient reports whether an error or one of its details is about a recipient
+func mentionsRecipient(e azureError) bool {
+	for _, s := range []string{e.Code, e.Target} {
+		s = strings.ToLower(s)
+		if strings.Contains(s, "recipient") || strings.Contains(s, "address") {
+			return true
+		}
+	}
+	if e.InnerError != nil && strings.Contains(strings.ToLower(e.InnerError.Code), "recipient") {
+		return true
+	}
+	for _, detail := range e.Details {
+		if mentionsRecipient(detail) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfter reads the wait from the retry-after-ms, x-ms-retry-after-ms or Retry-After
+// headers. Retry-After holds seconds or an HTTP date.
+func retryAfter(header http.Header) time.Duration {
+	for _, name := range []string{"retry-after-ms", "x-ms-retry-after-ms"} {
+		if ms, err := strconv.Atoi(header.Get(name)); err == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond
+		}
+	}
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// Retryable reports whether a failed send may succeed when tried again, and how long
+// the service asked to wait first. Throttling, server failures and network errors are
+// retryable; rejected credentials, recipients and requests are not.
+func Retryable(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter, apiErr.Temporary()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return 0, true
+	}
+	return 0, false
+}
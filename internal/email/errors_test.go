@@ -0,0 +1,150 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseAPIError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		header     http.Header
+		body       string
+		kind       ErrorKind
+		code       string
+		retryAfter time.Duration
+		sentinel   error
+	}{
+		{
+			name:     "auth",
+			status:   http.StatusUnauthorized,
+			body:     `{"error":{"code":"Denied","message":"Denied by the resource provider."}}`,
+			kind:     ErrorAuth,
+			code:     "Denied",
+			sentinel: ErrAuth,
+		},
+		{
+			name:     "invalid recipient",
+			status:   http.StatusBadRequest,
+			body:     `{"error":{"code":"BadRequest","message":"Invalid email address format.","details":[{"code":"InvalidRecipientAddress","target":"recipients.to[0].address"}]}}`,
+			kind:     ErrorInvalidRecipient,
+			code:     "InvalidRecipientAddress",
+			sentinel: ErrInvalidRecipient,
+		},
+		{
+			name:     "invalid request",
+			status:   http.StatusBadRequest,
+			body:     `{"error":{"code":"InvalidSenderDomain","message":"The sender domain is not linked."}}`,
+			kind:     ErrorInvalidRequest,
+			code:     "InvalidSenderDomain",
+			sentinel: ErrInvalidRequest,
+		},
+		{
+			name:       "throttled",
+			status:     http.StatusTooManyRequests,
+			header:     http.Header{"Retry-After": []string{"30"}},
+			body:       `{"error":{"code":"TooManyRequests","message":"Rate limit exceeded."}}`,
+			kind:       ErrorThrottled,
+			code:       "TooManyRequests",
+			retryAfter: 30 * time.Second,
+			sentinel:   ErrThrottled,
+		},
+		{
+			name:       "throttled in milliseconds",
+			status:     http.StatusTooManyRequests,
+			header:     http.Header{"Retry-After-Ms": []string{"1500"}, "Retry-After": []string{"2"}},
+			kind:       ErrorThrottled,
+			retryAfter: 1500 * time.Millisecond,
+			sentinel:   ErrThrottled,
+		},
+		{
+			name:     "transient",
+			status:   http.StatusServiceUnavailable,
+			body:     "upstream unavailable",
+			kind:     ErrorTransient,
+			sentinel: ErrTransient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: tt.header}
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+			apiErr := parseAPIError(resp, []byte(tt.body))
+
+			if apiErr.Kind != tt.kind {
+				t.Errorf("Kind: got %s want %s", apiErr.Kind, tt.kind)
+			}
+			if apiErr.Code != tt.code {
+				t.Errorf("Code: got %q want %q", apiErr.Code, tt.code)
+			}
+			if apiErr.RetryAfter != tt.retryAfter {
+				t.Errorf("RetryAfter: got %v want %v", apiErr.RetryAfter, tt.retryAfter)
+			}
+			if !errors.Is(fmt.Errorf("wrapped: %w", apiErr), tt.sentinel) {
+				t.Errorf("Expected %v to match %v", apiErr, tt.sentinel)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+		after     time.Duration
+	}{
+		{"throttled", &APIError{Kind: ErrorThrottled, RetryAfter: time.Minute}, true, time.Minute},
+		{"transient", fmt.Errorf("send: %w", &APIError{Kind: ErrorTransient}), true, 0},
+		{"auth", &APIError{Kind: ErrorAuth}, false, 0},
+		{"invalid recipient", &APIError{Kind: ErrorInvalidRecipient}, false, 0},
+		{"network", fmt.Errorf("failed to send HTTP request: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), true, 0},
+		{"validation", errors.New("invalid email message: subject is required"), false, 0},
+	}
+
+	for _, tt := range tests {
+		after, retryable := Retryable(tt.err)
+		if retryable != tt.retryable || after != tt.after {
+			t.Errorf("%s: got (%v, %v) want (%v, %v)", tt.name, after, retryable, tt.after, tt.retryable)
+		}
+	}
+}
+
+func TestSendEmailReturnsTypedErrors(t *testing.T) {
+	server := newFakeACSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "12")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"code":"TooManyRequests","message":"Too many requests."}}`)
+	})
+
+	client, err := NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+
+	msg := EmailMessage{
+		From:    "test@test-domain.dev",
+		To:      []string{"recipient@example.com"},
+		Subject: "Test Subject",
+		Body:    "Test Body",
+	}
+	_, err = client.SendEmail(context.Background(), msg)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected an APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter != 12*time.Second || apiErr.Message != "Too many requests." {
+		t.Errorf("Unexpected error: %+v", apiErr)
+	}
+}
@@ -0,0 +1,64 @@
+package email
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// htmlIgnoredPattern matches elements whose content is not part of the text
+	htmlIgnoredPattern = regexp.MustCompile(`(?is)<(script|style|head|title)\b.*?</(script|style|head|title)\s*>|<!--.*?-->`)
+	// htmlTagPattern matches a single tag and captures whether it closes and its name
+	htmlTagPattern = regexp.MustCompile(`(?s)<(/?)([a-zA-Z][a-zA-Z0-9]*)\b[^>]*>`)
+	// blankLinesPattern matches runs of more than one empty line
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+	// spacesPattern matches runs of horizontal whitespace
+	spacesPattern = regexp.MustCompile(`[ \t\r\f\v]+`)
+)
+
+// HTMLToText derives a plaintext alternative from an HTML body. Block elements
+// become line breaks, table cells are separated by " | " and list items get a dash.
+func HTMLToText(body string) string {
+	body = htmlIgnoredPattern.ReplaceAllString(body, "")
+
+	// Whitespace in HTML source is not significant, line breaks come from the tags
+	body = spacesPattern.ReplaceAllString(strings.ReplaceAll(body, "\n", " "), " ")
+
+	body = htmlTagPattern.ReplaceAllStringFunc(body, func(tag string) string {
+		match := htmlTagPattern.FindStringSubmatch(tag)
+		closing, name := match[1] == "/", strings.ToLower(match[2])
+
+		switch name {
+		case "br":
+			return "\n"
+		case "p", "div", "h1", "h2", "h3", "h4", "h5", "h6", "table", "ul", "ol", "blockquote":
+			return "\n\n"
+		case "tr":
+			if closing {
+				return "\n"
+			}
+			return ""
+		case "li":
+			if closing {
+				return ""
+			}
+			return "\n- "
+		case "td", "th":
+			if closing {
+				return " | "
+			}
+			return ""
+		default:
+			return ""
+		}
+	})
+
+	lines := strings.Split(html.UnescapeString(body), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		lines[i] = strings.TrimSpace(strings.TrimSuffix(line, "|"))
+	}
+	text := strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(text, "\n\n"))
+}
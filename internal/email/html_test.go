@@ -0,0 +1,80 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestHTMLToText(t *testing.T) {
+	body := `<html><head><title>Ignored</title><style>p { color: red; }</style></head><body>
+<h1>Order #1234 &amp; refund</h1>
+<p>Dear   support,<br>my order
+is still late.</p>
+<table><tr><th>#</th><th>Sent</th></tr><tr><td>1</td><td>2025-01-02</td></tr></table>
+<ul><li>First request</li><li>Second request</li></ul>
+<!-- tracking comment -->
+</body></html>`
+
+	expected := "Order #1234 & refund\n\n" +
+		"Dear support,\nmy order is still late.\n\n" +
+		"# | Sent\n1 | 2025-01-02\n\n" +
+		"- First request\n- Second request"
+	if got := HTMLToText(body); got != expected {
+		t.Errorf("HTMLToText() = %q, want %q", got, expected)
+	}
+}
+
+func TestSendEmailHTMLContent(t *testing.T) {
+	var received azureEmailRequest
+	server := newFakeACSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `{"id":"op-1","status":"Running"}`)
+	})
+
+	client, err := NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+
+	// Only HTML is given, so the plaintext is derived
+	msg := EmailMessage{
+		From:     "test@test-domain.dev",
+		To:       []string{"recipient@example.com"},
+		Subject:  "Test Subject",
+		HTMLBody: "<p>Where is my <b>order</b>?</p>",
+	}
+	if _, err := client.SendEmail(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	if received.Content.HTML != msg.HTMLBody {
+		t.Errorf("Expected html content %q, got %q", msg.HTMLBody, received.Content.HTML)
+	}
+	if received.Content.PlainText != "Where is my order?" {
+		t.Errorf("Expected derived plaintext, got %q", received.Content.PlainText)
+	}
+
+	// An explicit plaintext body is kept
+	msg.Body = "Plain version"
+	if _, err := client.SendEmail(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	if received.Content.PlainText != "Plain version" {
+		t.Errorf("Expected explicit plaintext, got %q", received.Content.PlainText)
+	}
+
+	// Plaintext only messages have no html content
+	msg.HTMLBody = ""
+	received = azureEmailRequest{}
+	if _, err := client.SendEmail(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	if received.Content.HTML != "" {
+		t.Errorf("Expected no html content, got %q", received.Content.HTML)
+	}
+}
@@ -0,0 +1,230 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// buildMIME renders the message as an RFC 5322 message with a quoted-printable
+// UTF-8 body, a multipart/alternative body when it has HTML and a multipart/mixed
+// body when it has attachments. BCC recipients are left out of the headers.
+func buildMIME(msg EmailMessage, messageID string, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+
+	from, err := formatAddressList([]string{msg.From})
+	if err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	to, err := formatAddressList(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("to: %w", err)
+	}
+
+	writeHeader(&buf, "From", from)
+	writeHeader(&buf, "To", to)
+	if len(msg.CC) > 0 {
+		cc, err := formatAddressList(msg.CC)
+		if err != nil {
+			return nil, fmt.Errorf("cc: %w", err)
+		}
+		writeHeader(&buf, "Cc", cc)
+	}
+	if msg.ReplyTo != "" {
+		replyTo, err := formatAddressList([]string{msg.ReplyTo})
+		if err != nil {
+			return nil, fmt.Errorf("reply-to: %w", err)
+		}
+		writeHeader(&buf, "Reply-To", replyTo)
+	}
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader(&buf, "Date", date.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", messageID)
+	headers := msg.threadHeaders()
+	for _, name := range []string{"In-Reply-To", "References"} {
+		if value, ok := headers[name]; ok {
+			writeHeader(&buf, name, value)
+		}
+	}
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	contentType, body, err := buildBody(msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.Attachments) > 0 {
+		contentType, body, err = attach(contentType, body, msg.Attachments)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	writeHeader(&buf, "Content-Type", contentType)
+	if !strings.HasPrefix(contentType, "multipart/") {
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// buildBody renders the text of the message: quoted-printable plaintext, or a
+// multipart/alternative body when it has HTML
+func buildBody(msg EmailMessage) (string, []byte, error) {
+	if msg.HTMLBody == "" {
+		var body bytes.Buffer
+		if err := writeQuotedPrintable(&body, msg.Body); err != nil {
+			return "", nil, err
+		}
+		return "text/plain; charset=utf-8", body.Bytes(), nil
+	}
+
+	// Clients show the last alternative they support, so HTML goes last
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := writeTextPart(mw, "text/plain; charset=utf-8", msg.PlainText()); err != nil {
+		return "", nil, err
+	}
+	if err := writeTextPart(mw, "text/html; charset=utf-8", msg.HTMLBody); err != nil {
+		return "", nil, err
+	}
+	if err := mw.Close(); err != nil {
+		return "", nil, fmt.Errorf("failed to close multipart body: %w", err)
+	}
+	return fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary()), body.Bytes(), nil
+}
+
+// attach wraps the text body in a multipart/mixed body followed by the attachments
+func attach(contentType string, content []byte, attachments []Attachment) (string, []byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	if !strings.HasPrefix(contentType, "multipart/") {
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+	}
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create body part: %w", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return "", nil, err
+	}
+
+	for _, a := range attachments {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", a.ContentType)
+		header.Set("Content-Transfer-Encoding", "base64")
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to create part for %s: %w", a.Name, err)
+		}
+		if err := writeBase64Lines(part, a.Content); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return "", nil, fmt.Errorf("failed to close multipart body: %w", err)
+	}
+	return fmt.Sprintf("multipart/mixed; boundary=%q", mw.Boundary()), body.Bytes(), nil
+}
+
+// writeBase64Lines writes content base64 encoded in lines of 76 characters
+func writeBase64Lines(w io.Writer, content []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+		if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
+
+// writeTextPart writes a quoted-printable text part to a multipart body
+func writeTextPart(mw *multipart.Writer, contentType, text string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("failed to create %s part: %w", contentType, err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeQuotedPrintable(&buf, text); err != nil {
+		return err
+	}
+	_, err = part.Write(buf.Bytes())
+	return err
+}
+
+// writeHeader writes a single header line
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	fmt.Fprintf(buf, "%s: %s\r\n", name, value)
+}
+
+// writeQuotedPrintable writes text with CRLF line endings in quoted-printable encoding
+func writeQuotedPrintable(buf *bytes.Buffer, text string) error {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\n", "\r\n")
+
+	w := quotedprintable.NewWriter(buf)
+	if _, err := w.Write([]byte(text)); err != nil {
+		return fmt.Errorf("failed to encode body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to encode body: %w", err)
+	}
+	buf.WriteString("\r\n")
+	return nil
+}
+
+// formatAddressList parses addresses such as "Jane <jane@example.com>" and formats
+// them for a header, encoding non-ASCII display names
+func formatAddressList(addresses []string) (string, error) {
+	formatted := make([]string, len(addresses))
+	for i, address := range addresses {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return "", fmt.Errorf("invalid address %q: %w", address, err)
+		}
+		formatted[i] = parsed.String()
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// newMessageID creates a unique Message-ID in the domain of the sender address
+func newMessageID(from string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate message id: %w", err)
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), senderDomain(from)), nil
+}
+
+// senderDomain returns the domain of the sender address, or localhost when it has none
+func senderDomain(from string) string {
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(parsed.Address, "@"); at >= 0 {
+			return parsed.Address[at+1:]
+		}
+	}
+	return "localhost"
+}
@@ -0,0 +1,268 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OperationStatus is the status of a long-running email send operation
+type OperationStatus string
+
+const (
+	OperationNotStarted OperationStatus = "NotStarted"
+	OperationRunning    OperationStatus = "Running"
+	OperationSucceeded  OperationStatus = "Succeeded"
+	OperationFailed     OperationStatus = "Failed"
+	OperationCanceled   OperationStatus = "Canceled"
+	// OperationUnknown is recorded by the Poller when ACS no longer knows an operation
+	OperationUnknown OperationStatus = "Unknown"
+)
+
+// defaultOperationRetention is how long the Poller keeps an operation it stopped following
+const defaultOperationRetention = time.Hour
+
+// ErrOperationNotFound is returned when ACS does not know the operation id
+var ErrOperationNotFound = errors.New("email operation not found")
+
+// Terminal reports whether the operation has finished
+func (s OperationStatus) Terminal() bool {
+	return s == OperationSucceeded || s == OperationFailed || s == OperationCanceled
+}
+
+// Operation represents the state of an email send operation
+type Operation struct {
+	ID     string          `json:"id"`
+	Status OperationStatus `json:"status"`
+	Error  *OperationError `json:"error,omitempty"`
+}
+
+// OperationError describes why an operation failed
+type OperationError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// GetOperation fetches the current state of an email send operation.
+// The returned duration is the polling delay requested by the service, or zero.
+func (ec *EmailClient) GetOperation(ctx context.Context, id string) (Operation, time.Duration, error) {
+	reqURL := fmt.Sprintf("%s/emails/operations/%s?api-version=%s", ec.endpoint, url.PathEscape(id), apiVersion)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return Operation{}, 0, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if err := ec.signer.Sign(req, nil); err != nil {
+		return Operation{}, 0, fmt.Errorf("failed to sign HTTP request: %w", err)
+	}
+
+	resp, err := ec.httpClient.Do(req)
+	if err != nil {
+		return Operation{}, 0, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Operation{}, 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Operation{}, 0, fmt.Errorf("%w: %s", ErrOperationNotFound, id)
+	}
+	if resp.StatusCode >= 400 {
+		return Operation{}, 0, fmt.Errorf("email operation lookup failed: %w", parseAPIError(resp, body))
+	}
+
+	var op Operation
+	if err := json.Unmarshal(body, &op); err != nil {
+		return Operation{}, 0, fmt.Errorf("failed to decode response body: %w", err)
+	}
+	if op.ID == "" {
+		op.ID = id
+	}
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	return op, retryAfter, nil
+}
+
+// operationIDFromLocation extracts the operation id from an Operation-Location header
+func operationIDFromLocation(location string) string {
+	if location == "" {
+		return ""
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return ""
+	}
+	id := path.Base(u.Path)
+	if id == "." || id == "/" {
+		return ""
+	}
+	return id
+}
+
+// OperationGetter fetches the state of email send operations
+type OperationGetter interface {
+	GetOperation(ctx context.Context, id string) (Operation, time.Duration, error)
+}
+
+// Poller follows email send operations in the background until they succeed or fail.
+// Operations it stopped following are forgotten after a while.
+type Poller struct {
+	client   OperationGetter
+	interval time.Duration
+	maxWait  time.Duration
+	// retention is how long an operation is kept after it stopped being followed
+	retention time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu  sync.RWMutex
+	ops map[string]trackedOperation
+}
+
+// trackedOperation is the last known state of an operation followed by the Poller
+type trackedOperation struct {
+	op Operation
+	// done is when the Poller stopped following the operation, or zero while it still does
+	done time.Time
+}
+
+// expired reports whether the operation was kept long enough after it stopped being followed
+func (t trackedOperation) expired(now time.Time, retention time.Duration) bool {
+	return !t.done.IsZero() && now.Sub(t.done) > retention
+}
+
+// NewPoller creates a new poller that checks operations every interval for at most maxWait
+func NewPoller(client OperationGetter, interval, maxWait time.Duration) *Poller {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Poller{
+		client:    client,
+		interval:  interval,
+		maxWait:   maxWait,
+		retention: defaultOperationRetention,
+		ctx:       ctx,
+		cancel:    cancel,
+		ops:       make(map[string]trackedOperation),
+	}
+}
+
+// Track starts following the operation in the background
+func (p *Poller) Track(id string) {
+	p.mu.Lock()
+	p.prune(time.Now())
+	if _, ok := p.ops[id]; ok {
+		p.mu.Unlock()
+		return
+	}
+	p.ops[id] = trackedOperation{op: Operation{ID: id, Status: OperationRunning}}
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.poll(id)
+	}()
+}
+
+// Status returns the last known state of a tracked operation
+func (p *Poller) Status(id string) (Operation, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	t, ok := p.ops[id]
+	if !ok || t.expired(time.Now(), p.retention) {
+		return Operation{}, false
+	}
+	return t.op, true
+}
+
+// prune forgets the operations kept longer than the retention. The caller holds p.mu.
+func (p *Poller) prune(now time.Time) {
+	for id, t := range p.ops {
+		if t.expired(now, p.retention) {
+			delete(p.ops, id)
+		}
+	}
+}
+
+// finish records the final state of an operation the Poller stops following
+func (p *Poller) finish(id string, status OperationStatus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t := p.ops[id]
+	if status != "" {
+		t.op.Status = status
+	}
+	t.done = time.Now()
+	p.ops[id] = t
+}
+
+// Stop stops following all operations and waits for the pollers to exit
+func (p *Poller) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+// poll fetches the operation until it is terminal, the deadline passes or the poller
+// stops. An operation that ACS does not know is recorded as unknown.
+func (p *Poller) poll(id string) {
+	ctx, cancel := context.WithTimeout(p.ctx, p.maxWait)
+	defer cancel()
+
+	delay := p.interval
+	for {
+		select {
+		case <-ctx.Done():
+			p.finish(id, "")
+			return
+		case <-time.After(delay):
+		}
+
+		op, retryAfter, err := p.client.GetOperation(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrOperationNotFound) {
+				log.Printf("Email operation %s is not known to ACS", id)
+				p.finish(id, OperationUnknown)
+				return
+			}
+			if ctx.Err() != nil {
+				p.finish(id, "")
+				return
+			}
+			log.Printf("Failed to poll email operation %s: %v", id, err)
+			delay = p.interval
+			continue
+		}
+
+		p.mu.Lock()
+		p.ops[id] = trackedOperation{op: op}
+		p.mu.Unlock()
+
+		if op.Status.Terminal() {
+			log.Printf("Email operation %s finished with status %s", id, op.Status)
+			p.finish(id, "")
+			return
+		}
+
+		delay = p.interval
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+	}
+}
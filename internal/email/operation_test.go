@@ -0,0 +1,183 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSendEmailReturnsOperationID(t *testing.T) {
+	msg := EmailMessage{
+		From:    "test@test-domain.dev",
+		To:      []string{"recipient@example.com"},
+		Subject: "Test Subject",
+		Body:    "Test Body",
+	}
+
+	// The id is taken from the response body
+	server := newFakeACSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `{"id":"op-body","status":"Running"}`)
+	})
+	client, err := NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+	id, err := client.SendEmail(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	if id != "op-body" {
+		t.Errorf("Expected operation id %s, got %s", "op-body", id)
+	}
+
+	// Without a body the id comes from the Operation-Location header
+	server = newFakeACSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Operation-Location", "https://acs.example.com/emails/operations/op-header?api-version=2023-03-31")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	client, err = NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+	id, err = client.SendEmail(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	if id != "op-header" {
+		t.Errorf("Expected operation id %s, got %s", "op-header", id)
+	}
+}
+
+func TestGetOperation(t *testing.T) {
+	server := newFakeACSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/emails/operations/op-1":
+			w.Header().Set("Retry-After", "7")
+			fmt.Fprint(w, `{"id":"op-1","status":"Failed","error":{"code":"InvalidRecipient","message":"mailbox does not exist"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	client, err := NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+
+	op, retryAfter, err := client.GetOperation(context.Background(), "op-1")
+	if err != nil {
+		t.Fatalf("Failed to get operation: %v", err)
+	}
+	if op.Status != OperationFailed || op.Error == nil || op.Error.Code != "InvalidRecipient" {
+		t.Errorf("Unexpected operation: %+v", op)
+	}
+	if retryAfter != 7*time.Second {
+		t.Errorf("Expected retry after %v, got %v", 7*time.Second, retryAfter)
+	}
+
+	if _, _, err := client.GetOperation(context.Background(), "missing"); !errors.Is(err, ErrOperationNotFound) {
+		t.Errorf("Expected ErrOperationNotFound, got %v", err)
+	}
+}
+
+func TestPollerFollowsOperationToCompletion(t *testing.T) {
+	var polls atomic.Int32
+	server := newFakeACSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// The operation succeeds on the third poll
+		if polls.Add(1) < 3 {
+			fmt.Fprint(w, `{"id":"op-1","status":"Running"}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"op-1","status":"Succeeded"}`)
+	})
+	client, err := NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+
+	poller := NewPoller(client, time.Millisecond, time.Minute)
+	defer poller.Stop()
+
+	poller.Track("op-1")
+	if op, ok := poller.Status("op-1"); !ok || op.Status.Terminal() {
+		t.Errorf("Expected a tracked running operation, got %+v", op)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if op, _ := poller.Status("op-1"); op.Status == OperationSucceeded {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if op, _ := poller.Status("op-1"); op.Status != OperationSucceeded {
+		t.Fatalf("Expected operation to succeed, got %+v", op)
+	}
+
+	// No further polls once the operation is terminal
+	count := polls.Load()
+	time.Sleep(10 * time.Millisecond)
+	if polls.Load() != count {
+		t.Error("Poller kept polling a finished operation")
+	}
+
+	if _, ok := poller.Status("unknown"); ok {
+		t.Error("Unknown operation should not be tracked")
+	}
+}
+
+func TestPollerForgetsFinishedOperations(t *testing.T) {
+	server := newFakeACSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/op-gone") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"id":"op-1","status":"Succeeded"}`)
+	})
+	client, err := NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+
+	poller := NewPoller(client, time.Millisecond, time.Minute)
+	defer poller.Stop()
+
+	// Operations that ACS does not know are not reported as running forever
+	poller.Track("op-gone")
+	poller.Track("op-1")
+	waitForStatus := func(id string, status OperationStatus) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if op, _ := poller.Status(id); op.Status == status {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		op, _ := poller.Status(id)
+		t.Fatalf("Expected %s to be %s, got %+v", id, status, op)
+	}
+	waitForStatus("op-gone", OperationUnknown)
+	waitForStatus("op-1", OperationSucceeded)
+
+	// Once the retention has passed they are forgotten
+	poller.mu.Lock()
+	poller.retention = 0
+	poller.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	if _, ok := poller.Status("op-1"); ok {
+		t.Error("Expected the finished operation to be forgotten")
+	}
+	poller.Track("op-2")
+	poller.mu.RLock()
+	remaining := len(poller.ops)
+	poller.mu.RUnlock()
+	if remaining != 1 {
+		t.Errorf("Expected only the new operation to be kept, got %d", remaining)
+	}
+}
@@ -0,0 +1,100 @@
+package email
+
+import (
+	"complaint-escalator/internal/backoff"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DefaultRetryPolicy retries a transient failure twice within a few seconds. Longer
+// outages are left to the caller, which can try again much later.
+var DefaultRetryPolicy = backoff.Policy{
+	Strategy:    backoff.StrategyExponential,
+	Initial:     2 * time.Second,
+	Jitter:      backoff.JitterFull,
+	MaxDelay:    30 * time.Second,
+	MaxAttempts: 3,
+}
+
+// RetrySender retries the sends of another Sender that fail with a retryable error
+type RetrySender struct {
+	sender Sender
+	policy backoff.Policy
+	// wait blocks for the given duration or until the context is done
+	wait func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetrySender creates a new sender that retries sender following policy, which must
+// limit the number of attempts
+func NewRetrySender(sender Sender, policy backoff.Policy) (*RetrySender, error) {
+	if sender == nil {
+		return nil, fmt.Errorf("sender is required")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("retry policy: %w", err)
+	}
+	if policy.MaxAttempts == 0 {
+		return nil, fmt.Errorf("retry policy: max attempts is required")
+	}
+	return &RetrySender{sender: sender, policy: policy, wait: backoff.Sleep}, nil
+}
+
+// SendEmail sends the message and retries failures that Retryable accepts, waiting at
+// least as long as the service asked. When it asks for a wait longer than the maximum
+// delay of the policy, or the wait would outlast the context deadline, the error is
+// returned right away, so the caller can try later.
+//
+// Every attempt carries the same request id. A send that timed out after ACS accepted
+// it is therefore not delivered twice.
+func (r *RetrySender) SendEmail(ctx context.Context, msg EmailMessage) (string, error) {
+	if msg.RequestID == "" {
+		id, err := newRequestID()
+		if err != nil {
+			return "", err
+		}
+		msg.RequestID = id
+		msg.FirstSent = time.Now()
+	}
+
+	var previous time.Duration
+	for attempt := 1; ; attempt++ {
+		id, err := r.sender.SendEmail(ctx, msg)
+		if err == nil {
+			return id, nil
+		}
+
+		after, ok := Retryable(err)
+		if !ok || ctx.Err() != nil || r.policy.Exhausted(attempt) {
+			return "", err
+		}
+		if r.policy.MaxDelay > 0 && after > r.policy.MaxDelay {
+			return "", err
+		}
+
+		delay := r.policy.Delay(attempt, previous)
+		previous = delay
+		delay = max(delay, after)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return "", err
+		}
+
+		log.Printf("Email send attempt %d failed, retrying in %v: %v", attempt, delay, err)
+		if err := r.wait(ctx, delay); err != nil {
+			return "", err
+		}
+	}
+}
+
+// newRequestID creates a random version 4 UUID, the format ACS expects for request ids
+func newRequestID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate request id: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
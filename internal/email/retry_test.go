@@ -0,0 +1,193 @@
+package email
+
+import (
+	"complaint-escalator/internal/backoff"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+// scriptedSender fails with the given errors in turn and then succeeds
+type scriptedSender struct {
+	errs  []error
+	calls int
+	// requestIDs are the request ids of the sent messages
+	requestIDs []string
+}
+
+func (s *scriptedSender) SendEmail(ctx context.Context, msg EmailMessage) (string, error) {
+	s.calls++
+	s.requestIDs = append(s.requestIDs, msg.RequestID)
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return "", err
+	}
+	return "op-1", nil
+}
+
+// newTestRetrySender creates a retry sender that records its waits instead of sleeping
+func newTestRetrySender(t *testing.T, sender Sender, waits *[]time.Duration) *RetrySender {
+	t.Helper()
+	policy := backoff.Policy{Strategy: backoff.StrategyExponential, Initial: time.Second, MaxDelay: 10 * time.Second, MaxAttempts: 3}
+	r, err := NewRetrySender(sender, policy)
+	if err != nil {
+		t.Fatalf("Failed to create retry sender: %v", err)
+	}
+	r.wait = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	return r
+}
+
+func TestRetrySender(t *testing.T) {
+	tests := []struct {
+		name    string
+		errs    []error
+		calls   int
+		waits   []time.Duration
+		succeed bool
+	}{
+		{"success", nil, 1, nil, true},
+		{"transient then success", []error{&APIError{Kind: ErrorTransient}}, 2, []time.Duration{time.Second}, true},
+		{"respects retry after", []error{&APIError{Kind: ErrorThrottled, RetryAfter: 5 * time.Second}}, 2, []time.Duration{5 * time.Second}, true},
+		{"retry after beyond max delay", []error{&APIError{Kind: ErrorThrottled, RetryAfter: time.Minute}}, 1, nil, false},
+		{"invalid recipient", []error{&APIError{Kind: ErrorInvalidRecipient}}, 1, nil, false},
+		{"auth", []error{&APIError{Kind: ErrorAuth}}, 1, nil, false},
+		{"exhausted", []error{&APIError{Kind: ErrorTransient}, &APIError{Kind: ErrorTransient}, &APIError{Kind: ErrorTransient}}, 3, []time.Duration{time.Second, 2 * time.Second}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &scriptedSender{errs: tt.errs}
+			var waits []time.Duration
+			r := newTestRetrySender(t, sender, &waits)
+
+			id, err := r.SendEmail(context.Background(), EmailMessage{})
+			if tt.succeed && (err != nil || id != "op-1") {
+				t.Errorf("Expected success, got %q, %v", id, err)
+			}
+			if !tt.succeed && err == nil {
+				t.Error("Expected an error")
+			}
+			if sender.calls != tt.calls {
+				t.Errorf("Expected %d calls, got %d", tt.calls, sender.calls)
+			}
+			if len(waits) != len(tt.waits) {
+				t.Fatalf("Expected waits %v, got %v", tt.waits, waits)
+			}
+			for i := range waits {
+				if waits[i] != tt.waits[i] {
+					t.Errorf("Wait %d: got %v want %v", i+1, waits[i], tt.waits[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRetrySenderKeepsErrorType(t *testing.T) {
+	sender := &scriptedSender{errs: []error{&APIError{Kind: ErrorThrottled, RetryAfter: time.Hour}}}
+	var waits []time.Duration
+	r := newTestRetrySender(t, sender, &waits)
+
+	// The caller can still tell that it should try again later
+	_, err := r.SendEmail(context.Background(), EmailMessage{})
+	if !errors.Is(err, ErrThrottled) {
+		t.Errorf("Expected a throttled error, got %v", err)
+	}
+	if after, ok := Retryable(err); !ok || after != time.Hour {
+		t.Errorf("Expected retryable after 1h, got %v, %v", after, ok)
+	}
+}
+
+func TestRetrySenderRepeatsRequestID(t *testing.T) {
+	sender := &scriptedSender{errs: []error{
+		fmt.Errorf("failed to send HTTP request: %w", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}),
+		&APIError{Kind: ErrorTransient},
+	}}
+	var waits []time.Duration
+	r := newTestRetrySender(t, sender, &waits)
+
+	if _, err := r.SendEmail(context.Background(), EmailMessage{}); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	// ACS must see every attempt as the same send, so a timed out send is not delivered twice
+	if len(sender.requestIDs) != 3 || sender.requestIDs[0] == "" {
+		t.Fatalf("Expected 3 attempts with a request id, got %q", sender.requestIDs)
+	}
+	for _, id := range sender.requestIDs[1:] {
+		if id != sender.requestIDs[0] {
+			t.Errorf("Request id changed between attempts: %q", sender.requestIDs)
+		}
+	}
+
+	// Separate sends get separate ids
+	if _, err := r.SendEmail(context.Background(), EmailMessage{}); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if sender.requestIDs[3] == sender.requestIDs[0] {
+		t.Errorf("Expected a new request id for a new send, got %q", sender.requestIDs)
+	}
+}
+
+func TestRetrySenderStopsAtDeadline(t *testing.T) {
+	sender := &scriptedSender{errs: []error{&APIError{Kind: ErrorTransient}}}
+	var waits []time.Duration
+	r := newTestRetrySender(t, sender, &waits)
+
+	// The first retry waits a second, which does not fit before the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	if _, err := r.SendEmail(ctx, EmailMessage{}); !errors.Is(err, ErrTransient) {
+		t.Errorf("Expected the transient error, got %v", err)
+	}
+	if sender.calls != 1 || len(waits) != 0 {
+		t.Errorf("Expected no retry, got %d calls and waits %v", sender.calls, waits)
+	}
+}
+
+func TestNewRetrySenderValidation(t *testing.T) {
+	if _, err := NewRetrySender(nil, DefaultRetryPolicy); err == nil {
+		t.Error("Expected error for a missing sender")
+	}
+	if _, err := NewRetrySender(&scriptedSender{}, backoff.Constant(time.Second)); err == nil {
+		t.Error("Expected error for a policy without max attempts")
+	}
+}
+
+func TestSendEmailRepeatabilityHeaders(t *testing.T) {
+	firstSent := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	server := newFakeACSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("repeatability-request-id"); got != "4f2c3a8e-1b7d-4e6a-9c5f-0d8e7b6a5c4d" {
+			t.Errorf("repeatability-request-id: got %q", got)
+		}
+		if got := r.Header.Get("repeatability-first-sent"); got != "Fri, 01 Mar 2024 09:30:00 GMT" {
+			t.Errorf("repeatability-first-sent: got %q", got)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `{"id":"op-1","status":"Running"}`)
+	})
+
+	client, err := NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+	msg := EmailMessage{
+		From:      "test@test-domain.dev",
+		To:        []string{"recipient@example.com"},
+		Subject:   "Test Subject",
+		Body:      "Test Body",
+		RequestID: "4f2c3a8e-1b7d-4e6a-9c5f-0d8e7b6a5c4d",
+		FirstSent: firstSent,
+	}
+	if _, err := client.SendEmail(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+}
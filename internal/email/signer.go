@@ -0,0 +1,58 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// signedHeaders lists the headers covered by the signature, in signing order
+const signedHeaders = "x-ms-date;host;x-ms-content-sha256"
+
+// Signer signs requests to Azure Communication Services with the HMAC-SHA256 scheme
+type Signer struct {
+	accessKey string
+	now       func() time.Time
+}
+
+// NewSigner creates a new signer for the base64 encoded access key of a connection string
+func NewSigner(accessKey string) *Signer {
+	return &Signer{
+		accessKey: accessKey,
+		now:       time.Now,
+	}
+}
+
+// Sign adds the x-ms-date, x-ms-content-sha256 and Authorization headers to the request.
+// body must be the exact request body, or nil for requests without one.
+func (s *Signer) Sign(req *http.Request, body []byte) error {
+	key, err := base64.StdEncoding.DecodeString(s.accessKey)
+	if err != nil {
+		return fmt.Errorf("access key is not valid base64: %w", err)
+	}
+
+	contentHash := sha256.Sum256(body)
+	contentHashB64 := base64.StdEncoding.EncodeToString(contentHash[:])
+	date := s.now().UTC().Format(http.TimeFormat)
+
+	// The string to sign is VERB\nPATH?QUERY\nDATE;HOST;CONTENT-HASH
+	stringToSign := fmt.Sprintf("%s\n%s\n%s;%s;%s",
+		req.Method,
+		req.URL.RequestURI(),
+		date,
+		req.URL.Host,
+		contentHashB64,
+	)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set("x-ms-date", date)
+	req.Header.Set("x-ms-content-sha256", contentHashB64)
+	req.Header.Set("Authorization", fmt.Sprintf("HMAC-SHA256 SignedHeaders=%s&Signature=%s", signedHeaders, signature))
+	return nil
+}
@@ -0,0 +1,164 @@
+package email
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testAccessKey is base64("complaint-escalator-test-key-32b")
+const testAccessKey = "Y29tcGxhaW50LWVzY2FsYXRvci10ZXN0LWtleS0zMmI="
+
+func TestSignerKnownVectors(t *testing.T) {
+	signer := NewSigner(testAccessKey)
+	signer.now = func() time.Time {
+		return time.Date(2025, 1, 7, 9, 30, 0, 0, time.UTC)
+	}
+
+	// Expected values were computed independently from the ACS signing specification
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		body          []byte
+		contentHash   string
+		authorization string
+	}{
+		{
+			name:          "send email",
+			method:        "POST",
+			url:           "https://test-acs.asiapacific.communication.azure.com/emails:send?api-version=2023-03-31",
+			body:          []byte(`{"senderAddress":"test@test-domain.dev"}`),
+			contentHash:   "UufJis/VhLANnbsFIK/0XxGwHfszAXD6TpPQmnjmpns=",
+			authorization: "HMAC-SHA256 SignedHeaders=x-ms-date;host;x-ms-content-sha256&Signature=21fNtJTlKnWYAfWkqL+9DycsTayYpHbPfPhRG7q0L9I=",
+		},
+		{
+			name:          "empty body",
+			method:        "GET",
+			url:           "https://test-acs.asiapacific.communication.azure.com/emails/operations/abc?api-version=2023-03-31",
+			body:          nil,
+			contentHash:   "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+			authorization: "HMAC-SHA256 SignedHeaders=x-ms-date;host;x-ms-content-sha256&Signature=lrvU0879lb//EXB0zACljOkw8C8bPqL8JipFrBSGHiA=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := signer.Sign(req, tt.body); err != nil {
+				t.Fatalf("Failed to sign request: %v", err)
+			}
+
+			if got := req.Header.Get("x-ms-date"); got != "Tue, 07 Jan 2025 09:30:00 GMT" {
+				t.Errorf("Expected x-ms-date %s, got %s", "Tue, 07 Jan 2025 09:30:00 GMT", got)
+			}
+			if got := req.Header.Get("x-ms-content-sha256"); got != tt.contentHash {
+				t.Errorf("Expected content hash %s, got %s", tt.contentHash, got)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.authorization {
+				t.Errorf("Expected authorization %s, got %s", tt.authorization, got)
+			}
+		})
+	}
+}
+
+func TestSignerInvalidKey(t *testing.T) {
+	req, err := http.NewRequest("POST", "https://example.com/emails:send", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewSigner("test-access-key").Sign(req, nil); err == nil {
+		t.Error("Expected error for access key that is not base64")
+	}
+}
+
+// verifyACSSignature checks a request the way the ACS service does
+func verifyACSSignature(r *http.Request, body []byte, accessKey string) error {
+	contentHash := sha256.Sum256(body)
+	if got := r.Header.Get("x-ms-content-sha256"); got != base64.StdEncoding.EncodeToString(contentHash[:]) {
+		return fmt.Errorf("content hash mismatch: %s", got)
+	}
+
+	date := r.Header.Get("x-ms-date")
+	if _, err := time.Parse(http.TimeFormat, date); err != nil {
+		return fmt.Errorf("invalid x-ms-date %q", date)
+	}
+
+	key, _ := base64.StdEncoding.DecodeString(accessKey)
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s;%s;%s", r.Method, r.URL.RequestURI(), date, r.Host, r.Header.Get("x-ms-content-sha256"))
+	expected := "HMAC-SHA256 SignedHeaders=x-ms-date;host;x-ms-content-sha256&Signature=" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if got := r.Header.Get("Authorization"); got != expected {
+		return fmt.Errorf("signature mismatch: %s", got)
+	}
+	return nil
+}
+
+// newFakeACSServer starts a local server that accepts correctly signed email requests
+func newFakeACSServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Failed to read request body: %v", err)
+		}
+		if err := verifyACSSignature(r, body, testAccessKey); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"error":{"code":"Denied","message":%q}}`, err.Error())
+			return
+		}
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSendEmailSignsRequest(t *testing.T) {
+	server := newFakeACSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/emails:send" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `{"id":"op-1","status":"Running"}`)
+	})
+
+	client, err := NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+
+	msg := EmailMessage{
+		From:    "test@test-domain.dev",
+		To:      []string{"recipient@example.com"},
+		Subject: "Test Subject",
+		Body:    "Test Body",
+	}
+	if _, err := client.SendEmail(context.Background(), msg); err != nil {
+		t.Fatalf("Expected signed request to be accepted: %v", err)
+	}
+
+	// A client with the wrong key is rejected by the fake service
+	wrongKey := base64.StdEncoding.EncodeToString([]byte("wrong-key"))
+	client, err = NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, wrongKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+	if _, err := client.SendEmail(context.Background(), msg); err == nil {
+		t.Error("Expected request signed with the wrong key to be rejected")
+	}
+}
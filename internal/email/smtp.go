@@ -0,0 +1,276 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sender sends email messages and returns the provider's operation or message id
+type Sender interface {
+	SendEmail(ctx context.Context, msg EmailMessage) (string, error)
+}
+
+// SMTP connection security modes
+const (
+	// SecurityStartTLS upgrades a plain connection with STARTTLS and fails if the server does not offer it
+	SecurityStartTLS = "starttls"
+	// SecurityTLS connects with implicit TLS, usually on port 465
+	SecurityTLS = "tls"
+	// SecurityNone sends in plain text. Only meant for local relays.
+	SecurityNone = "none"
+)
+
+// SMTP authentication mechanisms
+const (
+	AuthPlain = "plain"
+	AuthLogin = "login"
+)
+
+// SMTPConfig holds the settings of an SMTP server
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Security is one of SecurityStartTLS (the default), SecurityTLS or SecurityNone
+	Security string
+	// Username and Password enable authentication. Auth is AuthPlain (the default) or AuthLogin.
+	Username string
+	Password string
+	Auth     string
+}
+
+// SMTPClient sends email through an SMTP server
+type SMTPClient struct {
+	config    SMTPConfig
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	now       func() time.Time
+}
+
+// NewSMTPClient creates a new SMTP client for the server described by config
+func NewSMTPClient(config SMTPConfig) (*SMTPClient, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("host cannot be empty")
+	}
+	if config.Security == "" {
+		config.Security = SecurityStartTLS
+	}
+	if config.Port == 0 {
+		config.Port = 587
+		if config.Security == SecurityTLS {
+			config.Port = 465
+		}
+	}
+	if config.Auth == "" {
+		config.Auth = AuthPlain
+	}
+
+	switch config.Security {
+	case SecurityStartTLS, SecurityTLS, SecurityNone:
+	default:
+		return nil, fmt.Errorf("unknown security mode %q", config.Security)
+	}
+	switch config.Auth {
+	case AuthPlain, AuthLogin:
+	default:
+		return nil, fmt.Errorf("unknown auth mechanism %q", config.Auth)
+	}
+
+	return &SMTPClient{
+		config:    config,
+		tlsConfig: &tls.Config{ServerName: config.Host},
+		timeout:   30 * time.Second,
+		now:       time.Now,
+	}, nil
+}
+
+// SendEmail sends the message and returns its Message-ID.
+// BCC recipients receive the message but do not appear in its headers.
+func (c *SMTPClient) SendEmail(ctx context.Context, msg EmailMessage) (string, error) {
+	if err := validateMessage(msg); err != nil {
+		return "", fmt.Errorf("invalid email message: %w", err)
+	}
+
+	messageID := msg.MessageID
+	if messageID == "" {
+		var err error
+		if messageID, err = newMessageID(msg.From); err != nil {
+			return "", err
+		}
+	}
+	data, err := buildMIME(msg, messageID, c.now())
+	if err != nil {
+		return "", fmt.Errorf("failed to build message: %w", err)
+	}
+
+	client, err := c.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	if err := c.deliver(client, msg, data); err != nil {
+		return "", err
+	}
+
+	log.Printf("Email sent through SMTP. Message-ID: %s", messageID)
+	return messageID, nil
+}
+
+// dial connects to the server, secures the connection and authenticates
+func (c *SMTPClient) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+
+	dialer := &net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	// The context deadline covers the whole SMTP conversation
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if c.config.Security == SecurityTLS {
+		conn = tls.Client(conn, c.tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, c.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+
+	if c.config.Security == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("server %s does not support STARTTLS", addr)
+		}
+		if err := client.StartTLS(c.tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if c.config.Username != "" {
+		if err := client.Auth(c.auth()); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// deliver sends the envelope and message data over an established session
+func (c *SMTPClient) deliver(client *smtp.Client, msg EmailMessage, data []byte) error {
+	from, err := envelopeAddress(msg.From)
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+
+	recipients, err := envelopeRecipients(msg)
+	if err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("RCPT TO %s rejected: %w", rcpt, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return client.Quit()
+}
+
+// auth returns the configured authentication mechanism
+func (c *SMTPClient) auth() smtp.Auth {
+	if c.config.Auth == AuthLogin {
+		return &loginAuth{username: c.config.Username, password: c.config.Password, host: c.config.Host}
+	}
+	return smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.Host)
+}
+
+// envelopeRecipients returns every address the message is delivered to, including BCC
+func envelopeRecipients(msg EmailMessage) ([]string, error) {
+	var recipients []string
+	for _, list := range [][]string{msg.To, msg.CC, msg.BCC} {
+		for _, address := range list {
+			rcpt, err := envelopeAddress(address)
+			if err != nil {
+				return nil, err
+			}
+			recipients = append(recipients, rcpt)
+		}
+	}
+	return recipients, nil
+}
+
+// envelopeAddress returns the bare address of "Name <address>" for the SMTP envelope
+func envelopeAddress(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	return parsed.Address, nil
+}
+
+// loginAuth implements the LOGIN authentication mechanism, which net/smtp does not provide
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	// Like PLAIN, LOGIN sends the password in the clear, so it needs TLS or a local server
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+	}
+}
+
+// isLocalhost reports whether the host is the local machine
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
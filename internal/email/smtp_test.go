@@ -0,0 +1,428 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPMessage is a message received by the fake SMTP server
+type fakeSMTPMessage struct {
+	From string
+	To   []string
+	Data string
+	// User is the authenticated user, if any
+	User string
+	TLS  bool
+}
+
+// fakeSMTPServer is an in-process SMTP server that accepts the messages of one user
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	// implicitTLS serves TLS from the first byte instead of offering STARTTLS
+	implicitTLS bool
+	username    string
+	password    string
+
+	mu       sync.Mutex
+	messages []fakeSMTPMessage
+}
+
+// newFakeSMTPServer starts a fake SMTP server on a local port and returns it with a
+// certificate pool that trusts its certificate
+func newFakeSMTPServer(t *testing.T, implicitTLS bool) (*fakeSMTPServer, *x509.CertPool) {
+	t.Helper()
+
+	cert, pool := newTestCertificate(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &fakeSMTPServer{
+		listener:    listener,
+		tlsConfig:   &tls.Config{Certificates: []tls.Certificate{cert}},
+		implicitTLS: implicitTLS,
+		username:    "escalator",
+		password:    "secret",
+	}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+	return server, pool
+}
+
+// port returns the port the server listens on
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// received returns the messages received so far
+func (s *fakeSMTPServer) received() []fakeSMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeSMTPMessage(nil), s.messages...)
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle runs one SMTP session
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+
+	secure := false
+	if s.implicitTLS {
+		conn = tls.Server(conn, s.tlsConfig)
+		secure = true
+	}
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake.smtp ESMTP ready")
+
+	var msg fakeSMTPMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-fake.smtp")
+			if !secure {
+				tp.PrintfLine("250-STARTTLS")
+			}
+			tp.PrintfLine("250 AUTH PLAIN LOGIN")
+		case "STARTTLS":
+			tp.PrintfLine("220 Ready to start TLS")
+			conn = tls.Server(conn, s.tlsConfig)
+			tp = textproto.NewConn(conn)
+			secure = true
+		case "AUTH":
+			user, ok := s.authenticate(tp, arg)
+			if !ok {
+				tp.PrintfLine("535 Authentication failed")
+				continue
+			}
+			msg.User = user
+			tp.PrintfLine("235 Authentication succeeded")
+		case "MAIL":
+			msg.From = extractPath(arg)
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			msg.To = append(msg.To, extractPath(arg))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := bufio.NewReader(tp.DotReader()).ReadString(0)
+			if err != nil && data == "" {
+				return
+			}
+			msg.Data = data
+			msg.TLS = secure
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = fakeSMTPMessage{User: msg.User}
+			tp.PrintfLine("250 OK: queued")
+		case "RSET", "NOOP":
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// authenticate runs the PLAIN or LOGIN exchange and checks the credentials
+func (s *fakeSMTPServer) authenticate(tp *textproto.Conn, arg string) (string, bool) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	readResponse := func(challenge string) string {
+		tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(challenge)))
+		line, _ := tp.ReadLine()
+		decoded, _ := base64.StdEncoding.DecodeString(line)
+		return string(decoded)
+	}
+
+	var user, password string
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		var response string
+		if initial != "" {
+			decoded, _ := base64.StdEncoding.DecodeString(initial)
+			response = string(decoded)
+		} else {
+			response = readResponse("")
+		}
+		parts := strings.Split(response, "\x00")
+		if len(parts) != 3 {
+			return "", false
+		}
+		user, password = parts[1], parts[2]
+	case "LOGIN":
+		user = readResponse("Username:")
+		password = readResponse("Password:")
+	default:
+		return "", false
+	}
+	return user, user == s.username && password == s.password
+}
+
+// extractPath returns the address in "FROM:<address>" or "TO:<address>"
+func extractPath(arg string) string {
+	start, end := strings.Index(arg, "<"), strings.Index(arg, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
+
+// newTestCertificate creates a self-signed certificate for 127.0.0.1
+func newTestCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake.smtp"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// newTestSMTPClient creates a client for the fake server that trusts its certificate
+func newTestSMTPClient(t *testing.T, server *fakeSMTPServer, pool *x509.CertPool, config SMTPConfig) *SMTPClient {
+	t.Helper()
+
+	config.Host = "127.0.0.1"
+	config.Port = server.port()
+	client, err := NewSMTPClient(config)
+	if err != nil {
+		t.Fatalf("Failed to create SMTP client: %v", err)
+	}
+	client.tlsConfig = &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+	client.timeout = 5 * time.Second
+	return client
+}
+
+// testSMTPMessage is an email with every kind of recipient
+var testSMTPMessage = EmailMessage{
+	From:    "Complaint Escalator <noreply@test-domain.dev>",
+	To:      []string{"support@shop.example.com"},
+	CC:      []string{"cc-test@example.com"},
+	BCC:     []string{"bcc-test@example.com"},
+	ReplyTo: "reply-test@example.com",
+	Subject: "Bestellung #1234 fehlt – bitte prüfen",
+	Body:    "My order #1234 placed on 2025-01-02 has still not arrived.\nPlease refund 49,99 €.",
+}
+
+func TestSMTPClientSecurityModes(t *testing.T) {
+	tests := []struct {
+		name        string
+		implicitTLS bool
+		config      SMTPConfig
+	}{
+		{"STARTTLS with PLAIN", false, SMTPConfig{Security: SecurityStartTLS, Username: "escalator", Password: "secret"}},
+		{"STARTTLS with LOGIN", false, SMTPConfig{Security: SecurityStartTLS, Username: "escalator", Password: "secret", Auth: AuthLogin}},
+		{"implicit TLS", true, SMTPConfig{Security: SecurityTLS, Username: "escalator", Password: "secret"}},
+		{"plain relay", false, SMTPConfig{Security: SecurityNone}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, pool := newFakeSMTPServer(t, tt.implicitTLS)
+			client := newTestSMTPClient(t, server, pool, tt.config)
+
+			messageID, err := client.SendEmail(context.Background(), testSMTPMessage)
+			if err != nil {
+				t.Fatalf("Failed to send email: %v", err)
+			}
+			if !strings.HasPrefix(messageID, "<") || !strings.HasSuffix(messageID, "@test-domain.dev>") {
+				t.Errorf("Unexpected Message-ID %s", messageID)
+			}
+
+			received := server.received()
+			if len(received) != 1 {
+				t.Fatalf("Expected 1 message, got %d", len(received))
+			}
+			if received[0].TLS != (tt.config.Security != SecurityNone) {
+				t.Errorf("Expected TLS %v", tt.config.Security != SecurityNone)
+			}
+			if received[0].User != tt.config.Username {
+				t.Errorf("Expected user %q, got %q", tt.config.Username, received[0].User)
+			}
+		})
+	}
+}
+
+func TestSMTPClientBuildsMIME(t *testing.T) {
+	server, pool := newFakeSMTPServer(t, false)
+	client := newTestSMTPClient(t, server, pool, SMTPConfig{})
+	client.now = func() time.Time { return time.Date(2025, 1, 7, 9, 30, 0, 0, time.UTC) }
+
+	messageID, err := client.SendEmail(context.Background(), testSMTPMessage)
+	if err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	received := server.received()[0]
+	if received.From != "noreply@test-domain.dev" {
+		t.Errorf("Expected bare envelope sender, got %s", received.From)
+	}
+	expectedRcpt := []string{"support@shop.example.com", "cc-test@example.com", "bcc-test@example.com"}
+	if strings.Join(received.To, ",") != strings.Join(expectedRcpt, ",") {
+		t.Errorf("Expected envelope recipients %v, got %v", expectedRcpt, received.To)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(received.Data))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	header := parsed.Header
+	if header.Get("To") != "<support@shop.example.com>" || header.Get("Cc") != "<cc-test@example.com>" {
+		t.Errorf("Unexpected recipient headers: To %q, Cc %q", header.Get("To"), header.Get("Cc"))
+	}
+	if header.Get("Reply-To") != "<reply-test@example.com>" {
+		t.Errorf("Unexpected Reply-To %q", header.Get("Reply-To"))
+	}
+	if header.Get("Bcc") != "" || strings.Contains(received.Data, "bcc-test@example.com") {
+		t.Error("BCC recipients must not appear in the message")
+	}
+	if header.Get("Message-ID") != messageID || header.Get("Date") != "Tue, 07 Jan 2025 09:30:00 +0000" {
+		t.Errorf("Unexpected Message-ID %q or Date %q", header.Get("Message-ID"), header.Get("Date"))
+	}
+
+	decoder := new(mime.WordDecoder)
+	if subject, err := decoder.DecodeHeader(header.Get("Subject")); err != nil || subject != testSMTPMessage.Subject {
+		t.Errorf("Expected subject %q, got %q (%v)", testSMTPMessage.Subject, subject, err)
+	}
+	if header.Get("Content-Transfer-Encoding") != "quoted-printable" {
+		t.Errorf("Unexpected transfer encoding %q", header.Get("Content-Transfer-Encoding"))
+	}
+}
+
+func TestSMTPClientErrors(t *testing.T) {
+	if _, err := NewSMTPClient(SMTPConfig{}); err == nil {
+		t.Error("Expected error without host")
+	}
+	if _, err := NewSMTPClient(SMTPConfig{Host: "smtp.example.com", Security: "ssl3"}); err == nil {
+		t.Error("Expected error for unknown security mode")
+	}
+	if _, err := NewSMTPClient(SMTPConfig{Host: "smtp.example.com", Auth: "cram-md5"}); err == nil {
+		t.Error("Expected error for unknown auth mechanism")
+	}
+
+	// Wrong password
+	server, pool := newFakeSMTPServer(t, false)
+	client := newTestSMTPClient(t, server, pool, SMTPConfig{Username: "escalator", Password: "wrong"})
+	if _, err := client.SendEmail(context.Background(), testSMTPMessage); err == nil {
+		t.Error("Expected authentication error")
+	}
+
+	// Untrusted certificate
+	client = newTestSMTPClient(t, server, x509.NewCertPool(), SMTPConfig{})
+	if _, err := client.SendEmail(context.Background(), testSMTPMessage); err == nil {
+		t.Error("Expected certificate error")
+	}
+
+	if len(server.received()) != 0 {
+		t.Error("No message should have been delivered")
+	}
+}
+
+func TestSMTPClientHTMLAlternative(t *testing.T) {
+	server, pool := newFakeSMTPServer(t, false)
+	client := newTestSMTPClient(t, server, pool, SMTPConfig{})
+
+	msg := testSMTPMessage
+	msg.Body = ""
+	msg.HTMLBody = "<p>Where is my order?</p><table><tr><td>1</td><td>2025-01-02</td></tr></table>"
+	if _, err := client.SendEmail(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(server.received()[0].Data))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative, got %q (%v)", parsed.Header.Get("Content-Type"), err)
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var parts []string
+	var bodies []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read part: %v", err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, part.Header.Get("Content-Type"))
+		bodies = append(bodies, string(body))
+	}
+
+	if len(parts) != 2 || parts[0] != "text/plain; charset=utf-8" || parts[1] != "text/html; charset=utf-8" {
+		t.Fatalf("Expected plaintext and HTML parts, got %v", parts)
+	}
+	if strings.TrimSpace(bodies[0]) != "Where is my order?\n\n1 | 2025-01-02" {
+		t.Errorf("Unexpected derived plaintext %q", bodies[0])
+	}
+	if strings.TrimSpace(bodies[1]) != msg.HTMLBody {
+		t.Errorf("Unexpected HTML part %q", bodies[1])
+	}
+}
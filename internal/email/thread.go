@@ -0,0 +1,81 @@
+package email
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Follow-up subject styles
+const (
+	// SubjectRe prefixes follow-ups with "Re: "
+	SubjectRe = "re"
+	// SubjectFollowUp prefixes follow-ups with "[Follow-up #N] "
+	SubjectFollowUp = "follow-up"
+)
+
+// messageIDPattern matches a Message-ID such as <id@example.com>
+var messageIDPattern = regexp.MustCompile(`^<[^<>@\s]+@[^<>@\s]+>$`)
+
+// ThreadRootID returns the Message-ID that roots the thread identified by key.
+// The same key and sender always give the same id, so a thread survives restarts.
+func ThreadRootID(key, from string) string {
+	return fmt.Sprintf("<%s@%s>", key, senderDomain(from))
+}
+
+// ValidSubjectStyle reports whether style is empty or a known follow-up subject style
+func ValidSubjectStyle(style string) bool {
+	return style == "" || style == SubjectRe || style == SubjectFollowUp
+}
+
+// ThreadSubject returns the subject of the followUp-th follow-up in a thread.
+// The first message (followUp 0) and an empty style keep the subject unchanged.
+func ThreadSubject(subject, style string, followUp int) string {
+	if followUp < 1 {
+		return subject
+	}
+	switch style {
+	case SubjectRe:
+		if strings.HasPrefix(strings.ToLower(subject), "re:") {
+			return subject
+		}
+		return "Re: " + subject
+	case SubjectFollowUp:
+		return fmt.Sprintf("[Follow-up #%d] %s", followUp, subject)
+	default:
+		return subject
+	}
+}
+
+// threadHeaders returns the headers linking the message to its thread
+func (msg EmailMessage) threadHeaders() map[string]string {
+	headers := map[string]string{}
+	if msg.InReplyTo != "" {
+		headers["In-Reply-To"] = msg.InReplyTo
+	}
+	if len(msg.References) > 0 {
+		headers["References"] = strings.Join(msg.References, " ")
+	}
+	return headers
+}
+
+// acsThreadHeaders returns the thread headers for ACS, which assigns its own Message-ID.
+// A thread root Message-ID goes into References instead, and replies carry it along.
+func (msg EmailMessage) acsThreadHeaders() map[string]string {
+	if msg.MessageID != "" && !slices.Contains(msg.References, msg.MessageID) {
+		msg.References = append([]string{msg.MessageID}, msg.References...)
+	}
+	return msg.threadHeaders()
+}
+
+// validateThread validates the Message-ID and thread headers of a message
+func validateThread(msg EmailMessage) error {
+	ids := append([]string{msg.MessageID, msg.InReplyTo}, msg.References...)
+	for _, id := range ids {
+		if id != "" && !messageIDPattern.MatchString(id) {
+			return fmt.Errorf("invalid message id %q", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestThreadRootID(t *testing.T) {
+	id := ThreadRootID("complaint.order-1234.1700000000", "Escalator <test@test-domain.dev>")
+	if id != "<complaint.order-1234.1700000000@test-domain.dev>" {
+		t.Errorf("Unexpected thread root %q", id)
+	}
+	if id != ThreadRootID("complaint.order-1234.1700000000", "test@test-domain.dev") {
+		t.Error("Expected the same thread root for the same key and sender domain")
+	}
+}
+
+func TestThreadSubject(t *testing.T) {
+	tests := []struct {
+		style    string
+		followUp int
+		subject  string
+		expected string
+	}{
+		{SubjectRe, 0, "Order #1234", "Order #1234"},
+		{SubjectRe, 1, "Order #1234", "Re: Order #1234"},
+		{SubjectRe, 2, "RE: Order #1234", "RE: Order #1234"},
+		{SubjectFollowUp, 0, "Order #1234", "Order #1234"},
+		{SubjectFollowUp, 3, "Order #1234", "[Follow-up #3] Order #1234"},
+		{"", 3, "Order #1234", "Order #1234"},
+	}
+
+	for _, tt := range tests {
+		if got := ThreadSubject(tt.subject, tt.style, tt.followUp); got != tt.expected {
+			t.Errorf("ThreadSubject(%q, %q, %d): got %q want %q", tt.subject, tt.style, tt.followUp, got, tt.expected)
+		}
+	}
+
+	if !ValidSubjectStyle("") || !ValidSubjectStyle(SubjectFollowUp) || ValidSubjectStyle("fwd") {
+		t.Error("Unexpected subject style validation")
+	}
+}
+
+func TestSendEmailThreadHeaders(t *testing.T) {
+	var received azureEmailRequest
+	server := newFakeACSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `{"id":"op-1","status":"Running"}`)
+	})
+
+	client, err := NewEmailClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create email client: %v", err)
+	}
+
+	msg := EmailMessage{
+		From:       "test@test-domain.dev",
+		To:         []string{"recipient@example.com"},
+		Subject:    "Re: Test Subject",
+		Body:       "Test Body",
+		InReplyTo:  "<root@test-domain.dev>",
+		References: []string{"<root@test-domain.dev>"},
+	}
+	if _, err := client.SendEmail(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	if received.Headers["In-Reply-To"] != "<root@test-domain.dev>" || received.Headers["References"] != "<root@test-domain.dev>" {
+		t.Errorf("Expected thread headers, got %v", received.Headers)
+	}
+
+	// ACS assigns its own Message-ID, so the first message names the thread root in References
+	first := msg
+	first.Subject = "Test Subject"
+	first.MessageID = "<root@test-domain.dev>"
+	first.InReplyTo = ""
+	first.References = nil
+	received = azureEmailRequest{}
+	if _, err := client.SendEmail(context.Background(), first); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	if received.Headers["References"] != "<root@test-domain.dev>" || received.Headers["In-Reply-To"] != "" || received.Headers["Message-ID"] != "" {
+		t.Errorf("Expected the thread root in References, got %v", received.Headers)
+	}
+
+	// Header injection through a message id is rejected
+	msg.InReplyTo = "<root@test-domain.dev>\r\nBcc: someone@example.com"
+	if _, err := client.SendEmail(context.Background(), msg); err == nil {
+		t.Error("Expected error for invalid In-Reply-To")
+	}
+}
+
+func TestSMTPClientThreadHeaders(t *testing.T) {
+	server, pool := newFakeSMTPServer(t, false)
+	client := newTestSMTPClient(t, server, pool, SMTPConfig{})
+
+	// The first message of a thread uses the thread root as its Message-ID
+	first := testSMTPMessage
+	first.MessageID = "<root@test-domain.dev>"
+	id, err := client.SendEmail(context.Background(), first)
+	if err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	if id != first.MessageID {
+		t.Errorf("Expected Message-ID %s, got %s", first.MessageID, id)
+	}
+
+	followUp := testSMTPMessage
+	followUp.InReplyTo = "<root@test-domain.dev>"
+	followUp.References = []string{"<root@test-domain.dev>", "<second@test-domain.dev>"}
+	if _, err := client.SendEmail(context.Background(), followUp); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	received := server.received()
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(received))
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(received[0].Data))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	if parsed.Header.Get("Message-ID") != "<root@test-domain.dev>" || parsed.Header.Get("In-Reply-To") != "" {
+		t.Errorf("Unexpected headers of the first message: %v", parsed.Header)
+	}
+
+	parsed, err = mail.ReadMessage(strings.NewReader(received[1].Data))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	if parsed.Header.Get("In-Reply-To") != "<root@test-domain.dev>" {
+		t.Errorf("Expected In-Reply-To, got %q", parsed.Header.Get("In-Reply-To"))
+	}
+	if parsed.Header.Get("References") != "<root@test-domain.dev> <second@test-domain.dev>" {
+		t.Errorf("Expected References, got %q", parsed.Header.Get("References"))
+	}
+	if parsed.Header.Get("Message-ID") == "<root@test-domain.dev>" {
+		t.Error("Expected a new Message-ID for the follow-up")
+	}
+}
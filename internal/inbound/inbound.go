@@ -0,0 +1,180 @@
+package inbound
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// threadPrefix starts the thread keys of complaints
+const threadPrefix = "complaint."
+
+// Reply represents an inbound email that may answer a complaint
+type Reply struct {
+	From       string
+	To         []string
+	CC         []string
+	Subject    string
+	MessageID  string
+	InReplyTo  string
+	References []string
+	// Headers holds any other headers of the email, such as Auto-Submitted
+	Headers    map[string]string
+	ReceivedAt time.Time
+}
+
+// ThreadKey returns the key of the email thread of a complaint, the local part of its
+// thread root Message-ID. The creation time tells apart complaints that reuse an id.
+func ThreadKey(complaintID string, created time.Time) string {
+	return fmt.Sprintf("%s%s.%d", threadPrefix, complaintID, created.Unix())
+}
+
+// Matcher matches replies to the complaints they answer, through the thread headers
+// of the reply or a reply address tagged with the complaint id
+type Matcher struct {
+	// domain is the domain of thread root Message-IDs
+	domain string
+	// replyLocal and replyDomain split the reply address, which is tagged as local+id@domain
+	replyLocal  string
+	replyDomain string
+	// ignore holds addresses whose mail is never a reply, such as the sender itself
+	ignore map[string]bool
+}
+
+// NewMatcher creates a new matcher for threads rooted in the domain of the from
+// address. replyAddress is optional and enables matching through tagged addresses.
+func NewMatcher(from, replyAddress string) (*Matcher, error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", from, err)
+	}
+
+	m := &Matcher{
+		domain: domainOf(fromAddr.Address),
+		ignore: map[string]bool{strings.ToLower(fromAddr.Address): true},
+	}
+
+	if replyAddress != "" {
+		replyAddr, err := mail.ParseAddress(replyAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reply address %q: %w", replyAddress, err)
+		}
+		at := strings.LastIndex(replyAddr.Address, "@")
+		if strings.Contains(replyAddr.Address[:at], "+") {
+			return nil, fmt.Errorf("reply address %q must not have a tag", replyAddress)
+		}
+		m.replyLocal = strings.ToLower(replyAddr.Address[:at])
+		m.replyDomain = strings.ToLower(replyAddr.Address[at+1:])
+		m.ignore[strings.ToLower(replyAddr.Address)] = true
+	}
+	return m, nil
+}
+
+// ReplyAddress returns the reply address tagged with the complaint id, or an empty
+// string when no reply address is configured
+func (m *Matcher) ReplyAddress(complaintID string) string {
+	if m.replyLocal == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s+%s@%s", m.replyLocal, complaintID, m.replyDomain)
+}
+
+// Target identifies the complaint a reply answers
+type Target struct {
+	ComplaintID string
+	// Created is the creation time of the complaint from the thread key. It is zero for
+	// replies matched through their address, which does not carry it.
+	Created time.Time
+}
+
+// Answers reports whether the target is the complaint created at the given time, and not
+// an earlier complaint with the same id. Replies matched through their address always are.
+func (t Target) Answers(created time.Time) bool {
+	return t.Created.IsZero() || t.Created.Unix() == created.Unix()
+}
+
+// Match returns the complaint the reply answers. Automatic replies such as
+// out-of-office notices and mail from the escalator itself never match.
+func (m *Matcher) Match(reply Reply) (Target, bool) {
+	if isAutomatic(reply) {
+		return Target{}, false
+	}
+	if from, err := mail.ParseAddress(reply.From); err == nil && m.ignore[strings.ToLower(from.Address)] {
+		return Target{}, false
+	}
+
+	// Thread headers survive forwarding within the company, so they come first
+	for _, id := range append([]string{reply.InReplyTo}, reply.References...) {
+		if target, ok := m.matchMessageID(id); ok {
+			return target, true
+		}
+	}
+
+	if m.replyLocal != "" {
+		for _, address := range append(append([]string{}, reply.To...), reply.CC...) {
+			if complaintID, ok := m.matchAddress(address); ok {
+				return Target{ComplaintID: complaintID}, true
+			}
+		}
+	}
+	return Target{}, false
+}
+
+// matchMessageID returns the complaint of a thread root Message-ID
+func (m *Matcher) matchMessageID(id string) (Target, bool) {
+	id = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(id), "<"), ">")
+	at := strings.LastIndex(id, "@")
+	if at < 0 || !strings.EqualFold(id[at+1:], m.domain) {
+		return Target{}, false
+	}
+
+	key, ok := strings.CutPrefix(id[:at], threadPrefix)
+	if !ok {
+		return Target{}, false
+	}
+	// The key ends with the creation time of the complaint
+	dot := strings.LastIndex(key, ".")
+	if dot <= 0 {
+		return Target{}, false
+	}
+	created, err := strconv.ParseInt(key[dot+1:], 10, 64)
+	if err != nil {
+		return Target{}, false
+	}
+	return Target{ComplaintID: key[:dot], Created: time.Unix(created, 0)}, true
+}
+
+// matchAddress returns the complaint id of a tagged reply address
+func (m *Matcher) matchAddress(address string) (string, bool) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", false
+	}
+	at := strings.LastIndex(parsed.Address, "@")
+	if !strings.EqualFold(parsed.Address[at+1:], m.replyDomain) {
+		return "", false
+	}
+
+	local, tag, ok := strings.Cut(parsed.Address[:at], "+")
+	if !ok || tag == "" || !strings.EqualFold(local, m.replyLocal) {
+		return "", false
+	}
+	return tag, true
+}
+
+// isAutomatic reports whether the reply was sent automatically (RFC 3834)
+func isAutomatic(reply Reply) bool {
+	for name, value := range reply.Headers {
+		if strings.EqualFold(name, "Auto-Submitted") && !strings.EqualFold(strings.TrimSpace(value), "no") {
+			return true
+		}
+	}
+	return false
+}
+
+// domainOf returns the domain of an address
+func domainOf(address string) string {
+	return address[strings.LastIndex(address, "@")+1:]
+}
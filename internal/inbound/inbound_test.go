@@ -0,0 +1,108 @@
+package inbound
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThreadKey(t *testing.T) {
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	if key := ThreadKey("order-1234", created); key != "complaint.order-1234.1735722000" {
+		t.Errorf("Unexpected thread key %q", key)
+	}
+}
+
+func TestMatcherThreadHeaders(t *testing.T) {
+	matcher, err := NewMatcher("Escalator <escalator@example.com>", "")
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		reply    Reply
+		expected string
+	}{
+		{"in reply to the root", Reply{From: "support@shop.example", InReplyTo: "<complaint.order.1234.1735722000@example.com>"}, "order.1234"},
+		{"root in references", Reply{From: "support@shop.example", InReplyTo: "<acs-generated@azurecomm.net>", References: []string{"<complaint.c1.1735722000@example.com>", "<acs-generated@azurecomm.net>"}}, "c1"},
+		{"root of another domain", Reply{From: "support@shop.example", InReplyTo: "<complaint.c1.1735722000@elsewhere.example>"}, ""},
+		{"root without creation time", Reply{From: "support@shop.example", InReplyTo: "<complaint.c1.x@example.com>"}, ""},
+		{"unrelated thread", Reply{From: "support@shop.example", InReplyTo: "<newsletter.42@example.com>"}, ""},
+		{"out of office", Reply{From: "support@shop.example", InReplyTo: "<complaint.c1.1735722000@example.com>", Headers: map[string]string{"Auto-Submitted": "auto-replied"}}, ""},
+		{"explicitly not automatic", Reply{From: "support@shop.example", InReplyTo: "<complaint.c1.1735722000@example.com>", Headers: map[string]string{"auto-submitted": "no"}}, "c1"},
+		{"from the escalator itself", Reply{From: "ESCALATOR@example.com", InReplyTo: "<complaint.c1.1735722000@example.com>"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, ok := matcher.Match(tt.reply)
+			if ok != (tt.expected != "") || target.ComplaintID != tt.expected {
+				t.Errorf("Match: got %q, %v want %q", target.ComplaintID, ok, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatcherReplyAddress(t *testing.T) {
+	matcher, err := NewMatcher("escalator@example.com", "Replies <replies@inbound.example.com>")
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	address := matcher.ReplyAddress("order-1234")
+	if address != "replies+order-1234@inbound.example.com" {
+		t.Errorf("Unexpected reply address %q", address)
+	}
+
+	if target, ok := matcher.Match(Reply{From: "support@shop.example", To: []string{"Escalator <" + address + ">"}}); !ok || target.ComplaintID != "order-1234" {
+		t.Errorf("Expected a match through the tagged address, got %q, %v", target.ComplaintID, ok)
+	}
+	if target, ok := matcher.Match(Reply{From: "support@shop.example", To: []string{"someone@example.com"}, CC: []string{address}}); !ok || target.ComplaintID != "order-1234" {
+		t.Errorf("Expected a match through CC, got %q, %v", target.ComplaintID, ok)
+	}
+	if _, ok := matcher.Match(Reply{From: "support@shop.example", To: []string{"replies@inbound.example.com"}}); ok {
+		t.Error("Expected no match for the untagged reply address")
+	}
+	if _, ok := matcher.Match(Reply{From: "support@shop.example", To: []string{"other+order-1234@inbound.example.com"}}); ok {
+		t.Error("Expected no match for another mailbox")
+	}
+
+	// Without a reply address there is nothing to tag
+	plain, err := NewMatcher("escalator@example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.ReplyAddress("order-1234") != "" {
+		t.Error("Expected no reply address")
+	}
+
+	if _, err := NewMatcher("escalator@example.com", "replies+tag@example.com"); err == nil {
+		t.Error("Expected error for a tagged reply address")
+	}
+	if _, err := NewMatcher("not an address", ""); err == nil {
+		t.Error("Expected error for an invalid sender address")
+	}
+}
+
+func TestTargetAnswers(t *testing.T) {
+	matcher, err := NewMatcher("escalator@example.com", "")
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	target, ok := matcher.Match(Reply{From: "support@shop.example", InReplyTo: "<" + ThreadKey("c1", created) + "@example.com>"})
+	if !ok {
+		t.Fatal("Expected a match")
+	}
+	if !target.Answers(created.Add(300 * time.Millisecond)) {
+		t.Error("Expected the reply to answer the complaint it was sent for")
+	}
+	// A complaint that reuses the id of a deleted one starts a new thread
+	if target.Answers(created.Add(time.Hour)) {
+		t.Error("Expected the reply not to answer a later complaint with the same id")
+	}
+	if !(Target{ComplaintID: "c1"}).Answers(created) {
+		t.Error("Expected a reply matched through its address to answer")
+	}
+}
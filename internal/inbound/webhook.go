@@ -0,0 +1,49 @@
+package inbound
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// webhookEmail is an inbound email as posted to the inbound webhook by a mail relay
+type webhookEmail struct {
+	From       string            `json:"from"`
+	To         []string          `json:"to"`
+	CC         []string          `json:"cc"`
+	Subject    string            `json:"subject"`
+	MessageID  string            `json:"messageId"`
+	InReplyTo  string            `json:"inReplyTo"`
+	References string            `json:"references"`
+	Headers    map[string]string `json:"headers"`
+	ReceivedAt time.Time         `json:"receivedAt"`
+}
+
+// ParseWebhook parses a delivery of the inbound webhook, a JSON array of emails.
+// Emails without a sender are skipped.
+func ParseWebhook(body []byte) ([]Reply, error) {
+	var emails []webhookEmail
+	if err := json.Unmarshal(body, &emails); err != nil {
+		return nil, fmt.Errorf("failed to decode emails: %w", err)
+	}
+
+	var replies []Reply
+	for _, email := range emails {
+		if email.From == "" {
+			continue
+		}
+		replies = append(replies, Reply{
+			From:       email.From,
+			To:         email.To,
+			CC:         email.CC,
+			Subject:    email.Subject,
+			MessageID:  email.MessageID,
+			InReplyTo:  email.InReplyTo,
+			References: strings.Fields(email.References),
+			Headers:    email.Headers,
+			ReceivedAt: email.ReceivedAt,
+		})
+	}
+	return replies, nil
+}
@@ -0,0 +1,46 @@
+package inbound
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWebhook(t *testing.T) {
+	body := `[{
+		"from": "Support <support@shop.example>",
+		"to": ["escalator@example.com"],
+		"subject": "Re: Order #1234",
+		"messageId": "<reply-1@shop.example>",
+		"inReplyTo": "<complaint.c1.1735722000@example.com>",
+		"references": "<complaint.c1.1735722000@example.com>  <second@example.com>",
+		"headers": {"Auto-Submitted": "no"},
+		"receivedAt": "2025-01-01T09:00:00Z"
+	}, {
+		"to": ["escalator@example.com"],
+		"subject": "No sender"
+	}]`
+
+	replies, err := ParseWebhook([]byte(body))
+	if err != nil {
+		t.Fatalf("Failed to parse emails: %v", err)
+	}
+
+	expected := []Reply{{
+		From:       "Support <support@shop.example>",
+		To:         []string{"escalator@example.com"},
+		Subject:    "Re: Order #1234",
+		MessageID:  "<reply-1@shop.example>",
+		InReplyTo:  "<complaint.c1.1735722000@example.com>",
+		References: []string{"<complaint.c1.1735722000@example.com>", "<second@example.com>"},
+		Headers:    map[string]string{"Auto-Submitted": "no"},
+		ReceivedAt: time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+	}}
+	if !reflect.DeepEqual(replies, expected) {
+		t.Errorf("Unexpected replies:\ngot  %+v\nwant %+v", replies, expected)
+	}
+
+	if _, err := ParseWebhook([]byte(`{"not": "a list"}`)); err == nil {
+		t.Error("Expected error for invalid body")
+	}
+}
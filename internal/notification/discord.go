@@ -0,0 +1,133 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// discordMessageLimit is the maximum length of the text of a Discord message
+	discordMessageLimit = 2000
+	// discordTitleLimit is the maximum length of an embed title
+	discordTitleLimit = 256
+)
+
+// DiscordNotifier is a Notifier that posts embeds to a Discord webhook
+type DiscordNotifier struct {
+	webhookURL string
+	poster     httpPoster
+	// progress lets a retried round skip the messages that were already posted.
+	// The webhook is its only target.
+	progress roundProgress[discordPayload]
+}
+
+type discordPayload struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title,omitempty"`
+	Description string         `json:"description"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+type discordMessage struct {
+	ID string `json:"id"`
+}
+
+type discordRateLimit struct {
+	// RetryAfter is in seconds, with fractions
+	RetryAfter float64 `json:"retry_after"`
+}
+
+// NewDiscordNotifier creates a new Discord notifier for the webhook URL
+func NewDiscordNotifier(webhookURL string) (*DiscordNotifier, error) {
+	if webhookURL == "" {
+		return nil, fmt.Errorf("webhook URL cannot be empty")
+	}
+
+	// wait=true makes Discord return the created message, including its id
+	separator := "?"
+	if strings.Contains(webhookURL, "?") {
+		separator = "&"
+	}
+
+	return &DiscordNotifier{
+		webhookURL: webhookURL + separator + "wait=true",
+		poster:     newHTTPPoster(discordRetryAfter),
+	}, nil
+}
+
+// Send posts the message as embeds, split into several messages when the text is
+// longer than a Discord message. The receipt id lists the created message ids. When the
+// round is retried after a message failed, the messages already posted are skipped.
+func (n *DiscordNotifier) Send(ctx context.Context, msg Message) (Receipt, error) {
+	payloads, err := discordMessagePayloads(msg, time.Now())
+	if err != nil {
+		return Receipt{}, fmt.Errorf("discord message: %w", err)
+	}
+	round := n.progress.round(msg, payloads)
+
+	var ids []string
+	for i := round.next(""); i < len(round.parts); i++ {
+		status, body, err := n.poster.postJSON(ctx, n.webhookURL, round.parts[i], nil)
+		if err != nil {
+			return Receipt{ID: strings.Join(ids, ",")}, fmt.Errorf("discord webhook failed: %w", err)
+		}
+		if status >= 300 {
+			return Receipt{ID: strings.Join(ids, ",")}, fmt.Errorf("discord webhook failed with status %d: %s", status, string(body))
+		}
+
+		round.delivered("")
+
+		var created discordMessage
+		if err := json.Unmarshal(body, &created); err == nil && created.ID != "" {
+			ids = append(ids, created.ID)
+		}
+	}
+	n.progress.finish(msg)
+	return Receipt{ID: strings.Join(ids, ","), SentAt: time.Now()}, nil
+}
+
+// discordMessagePayloads formats the message as one embed per message: the subject
+// as title of the first, the text split at the message limit, and the complaint
+// in the footer of the last
+func discordMessagePayloads(msg Message, now time.Time) ([]discordPayload, error) {
+	chunks, err := splitText(msg.Text, discordMessageLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads := make([]discordPayload, len(chunks))
+	for i, chunk := range chunks {
+		payloads[i] = discordPayload{Embeds: []discordEmbed{{Description: chunk}}}
+	}
+
+	first := &payloads[0].Embeds[0]
+	first.Title = truncate(msg.Subject, discordTitleLimit)
+
+	last := &payloads[len(payloads)-1].Embeds[0]
+	last.Timestamp = now.UTC().Format(time.RFC3339)
+	if msg.ComplaintID != "" {
+		last.Footer = &discordFooter{Text: fmt.Sprintf("Complaint %s · attempt %d", msg.ComplaintID, msg.Attempt)}
+	}
+	return payloads, nil
+}
+
+// discordRetryAfter reads the wait from the retry_after field of a 429 reply
+func discordRetryAfter(resp *http.Response, body []byte) time.Duration {
+	var limit discordRateLimit
+	if err := json.Unmarshal(body, &limit); err == nil && limit.RetryAfter > 0 {
+		return time.Duration(limit.RetryAfter * float64(time.Second))
+	}
+	return retryAfterHeader(resp, body)
+}
@@ -0,0 +1,161 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestDiscordNotifierSend(t *testing.T) {
+	var payloads []discordPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("wait") != "true" {
+			t.Errorf("Expected wait=true, got %s", r.URL.RawQuery)
+		}
+		var payload discordPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		payloads = append(payloads, payload)
+		fmt.Fprintf(w, `{"id":"%d","channel_id":"1"}`, 100+len(payloads))
+	}))
+	defer server.Close()
+
+	notifier, err := NewDiscordNotifier(server.URL + "/api/webhooks/1/token")
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	receipt, err := notifier.Send(context.Background(), Message{
+		ComplaintID: "late-delivery",
+		Attempt:     1,
+		Subject:     "Order #1234",
+		Text:        "Where is my order?",
+	})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if receipt.ID != "101" {
+		t.Errorf("Expected message id in the receipt, got %s", receipt.ID)
+	}
+
+	if len(payloads) != 1 || len(payloads[0].Embeds) != 1 {
+		t.Fatalf("Expected one embed, got %+v", payloads)
+	}
+	embed := payloads[0].Embeds[0]
+	if embed.Title != "Order #1234" || embed.Description != "Where is my order?" {
+		t.Errorf("Unexpected embed: %+v", embed)
+	}
+	if embed.Footer == nil || embed.Footer.Text != "Complaint late-delivery · attempt 1" {
+		t.Errorf("Unexpected footer: %+v", embed.Footer)
+	}
+}
+
+func TestDiscordMessagePayloadsSplitLongText(t *testing.T) {
+	text := strings.Repeat("Please refund my order.\n", 200)
+	payloads, err := discordMessagePayloads(Message{ComplaintID: "c1", Subject: "Refund", Text: text}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(payloads) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(payloads))
+	}
+	for i, payload := range payloads {
+		if n := utf8.RuneCountInString(payload.Embeds[0].Description); n > discordMessageLimit {
+			t.Errorf("Message %d has %d characters", i, n)
+		}
+	}
+	if payloads[0].Embeds[0].Title != "Refund" || payloads[1].Embeds[0].Title != "" {
+		t.Error("Only the first message should carry the subject")
+	}
+	if payloads[2].Embeds[0].Footer == nil || payloads[0].Embeds[0].Footer != nil {
+		t.Error("Only the last message should carry the footer")
+	}
+}
+
+func TestDiscordNotifierRateLimit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"message":"You are being rate limited.","retry_after":0.5,"global":false}`)
+			return
+		}
+		if requests == 2 {
+			fmt.Fprint(w, `{"id":"1"}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Unknown Webhook","code":10015}`)
+	}))
+	defer server.Close()
+
+	notifier, err := NewDiscordNotifier(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	waits := recordWaits(&notifier.poster)
+
+	if _, err := notifier.Send(context.Background(), Message{Text: "Text"}); err != nil {
+		t.Fatalf("Expected send to succeed after the rate limit: %v", err)
+	}
+	if len(*waits) != 1 || (*waits)[0] != 500*time.Millisecond {
+		t.Errorf("Expected one wait of 500ms, got %v", *waits)
+	}
+
+	if _, err := notifier.Send(context.Background(), Message{Text: "Text"}); err == nil || !strings.Contains(err.Error(), "Unknown Webhook") {
+		t.Errorf("Expected error with the Discord message, got %v", err)
+	}
+
+	if _, err := NewDiscordNotifier(""); err == nil {
+		t.Error("Expected error for empty webhook URL")
+	}
+}
+
+func TestDiscordNotifierRetrySkipsPostedMessages(t *testing.T) {
+	var payloads []discordPayload
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload discordPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		// The second message fails the first time
+		if len(payloads) == 1 && fail {
+			fail = false
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		payloads = append(payloads, payload)
+		fmt.Fprintf(w, `{"id":"%d"}`, 100+len(payloads))
+	}))
+	defer server.Close()
+
+	notifier, err := NewDiscordNotifier(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	msg := Message{ComplaintID: "c1", Attempt: 1, Subject: "Order", Text: strings.Repeat("a", discordMessageLimit+10)}
+	if _, err := notifier.Send(context.Background(), msg); err == nil {
+		t.Fatal("Expected an error for the failed message")
+	}
+
+	receipt, err := notifier.Send(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Failed to retry: %v", err)
+	}
+	if len(payloads) != 2 || payloads[1].Embeds[0].Title != "" || payloads[1].Embeds[0].Footer == nil {
+		t.Errorf("Expected the retry to post only the second message, got %d messages", len(payloads))
+	}
+	if receipt.ID != "102" {
+		t.Errorf("Unexpected receipt id %s", receipt.ID)
+	}
+}
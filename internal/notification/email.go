@@ -0,0 +1,52 @@
+package notification
+
+import (
+	"complaint-escalator/internal/email"
+	"context"
+	"fmt"
+	"time"
+)
+
+// EmailNotifier is a Notifier that sends messages as email
+type EmailNotifier struct {
+	sender       email.Sender
+	from         string
+	subjectStyle string
+}
+
+// NewEmailNotifier creates a new email notifier that sends from the given address.
+// Follow-ups in a thread get their subject marked according to subjectStyle, which is
+// email.SubjectRe, email.SubjectFollowUp or empty to keep the subject unchanged.
+func NewEmailNotifier(sender email.Sender, from, subjectStyle string) (*EmailNotifier, error) {
+	if !email.ValidSubjectStyle(subjectStyle) {
+		return nil, fmt.Errorf("unknown follow-up subject style %q", subjectStyle)
+	}
+	return &EmailNotifier{
+		sender:       sender,
+		from:         from,
+		subjectStyle: subjectStyle,
+	}, nil
+}
+
+// Send sends the message to its email recipients. With a ThreadID, the first round
+// starts the thread and later rounds reply to it.
+func (n *EmailNotifier) Send(ctx context.Context, msg Message) (Receipt, error) {
+	emailMsg := email.CreateEmailMessageFromConfig(n.from, msg.To, msg.CC, msg.BCC, msg.ReplyTo, msg.Subject, msg.Text)
+	emailMsg.HTMLBody = msg.HTML
+
+	if msg.ThreadID != "" {
+		if msg.Attempt <= 1 {
+			emailMsg.MessageID = msg.ThreadID
+		} else {
+			emailMsg.InReplyTo = msg.ThreadID
+			emailMsg.References = []string{msg.ThreadID}
+			emailMsg.Subject = email.ThreadSubject(msg.Subject, n.subjectStyle, msg.Attempt-1)
+		}
+	}
+
+	id, err := n.sender.SendEmail(ctx, emailMsg)
+	if err != nil {
+		return Receipt{}, err
+	}
+	return Receipt{ID: id, SentAt: time.Now()}, nil
+}
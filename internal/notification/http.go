@@ -0,0 +1,148 @@
+package notification
+
+import (
+	"bytes"
+	"complaint-escalator/internal/backoff"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxRateLimitRetries is how often a rate-limited request is retried before giving up
+	maxRateLimitRetries = 3
+	// maxRetryAfter caps how long a single rate limit wait may take
+	maxRetryAfter = time.Minute
+)
+
+// rateLimitBackoff spaces the retries when a service rate limits without saying for how long
+var rateLimitBackoff = backoff.Policy{
+	Strategy:    backoff.StrategyExponential,
+	Initial:     time.Second,
+	Jitter:      backoff.JitterDecorrelated,
+	MaxDelay:    maxRetryAfter,
+	MaxAttempts: maxRateLimitRetries + 1,
+}
+
+// RateLimitError is returned when a service is still rate limiting after every retry
+type RateLimitError struct {
+	// RetryAfter is the wait the service asked for in its last response
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter)
+}
+
+// RetryAfterFunc extracts the requested wait from a 429 response
+type RetryAfterFunc func(resp *http.Response, body []byte) time.Duration
+
+// httpPoster posts JSON payloads and waits out rate limits
+type httpPoster struct {
+	client *http.Client
+	// retryAfter reads the wait from a 429 response
+	retryAfter RetryAfterFunc
+	// backoff spaces retries when a 429 response does not say how long to wait
+	backoff backoff.Policy
+	// wait blocks for the given duration or until the context is done
+	wait func(ctx context.Context, d time.Duration) error
+}
+
+// newHTTPPoster creates a new poster that reads rate limit waits with retryAfter
+func newHTTPPoster(retryAfter RetryAfterFunc) httpPoster {
+	return httpPoster{
+		client:     &http.Client{Timeout: 30 * time.Second},
+		retryAfter: retryAfter,
+		backoff:    rateLimitBackoff,
+		wait:       backoff.Sleep,
+	}
+}
+
+// postJSON posts the payload to endpoint, retrying while the service answers 429.
+// Any other response is returned to the caller with its status code and body.
+func (p httpPoster) postJSON(ctx context.Context, endpoint string, payload interface{}, header http.Header) (int, []byte, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return p.post(ctx, endpoint, jsonData, header)
+}
+
+// post posts the JSON body to endpoint, retrying while the service answers 429.
+// Errors never contain endpoint, as webhook and bot URLs carry secrets.
+func (p httpPoster) post(ctx context.Context, endpoint string, jsonData []byte, header http.Header) (int, []byte, error) {
+	var previous time.Duration
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jsonData))
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create HTTP request: %w", withoutURL(err))
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for key, values := range header {
+			req.Header[key] = values
+		}
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to send HTTP request: %w", withoutURL(err))
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusTooManyRequests {
+			return resp.StatusCode, body, nil
+		}
+
+		delay := p.retryAfter(resp, body)
+		if delay <= 0 {
+			delay = p.backoff.Delay(attempt, previous)
+		}
+		previous = delay
+		if p.backoff.Exhausted(attempt) || delay > maxRetryAfter {
+			return resp.StatusCode, body, &RateLimitError{RetryAfter: delay}
+		}
+		if err := p.wait(ctx, delay); err != nil {
+			return 0, nil, err
+		}
+	}
+}
+
+// withoutURL drops the request URL that *url.Error adds to transport errors. The errors
+// end up in the service log, the send history and the payloads of outbound webhooks.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// redactURL reduces a URL to its scheme and host, for naming it in errors
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "invalid URL"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// retryAfterHeader reads the wait from a Retry-After header in seconds or as an HTTP date
+func retryAfterHeader(resp *http.Response, body []byte) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
@@ -1,5 +1,211 @@
 package notification
 
-func SendNotification(message string) {
-	// TODO: Implement notification sending logic
-} 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnknownChannel is returned when no notifier is registered for a channel
+var ErrUnknownChannel = errors.New("unknown channel")
+
+// Message is the content of a complaint escalation sent through a channel
+type Message struct {
+	ComplaintID string
+	// Attempt is the escalation round, starting at 1
+	Attempt int
+	Subject string
+	Text    string
+	// HTML is an optional HTML version of Text for channels that support it
+	HTML string
+	// Email recipients. Channels that are not email ignore them.
+	To      []string
+	CC      []string
+	BCC     []string
+	ReplyTo string
+	// ThreadID is the Message-ID rooting the email thread of the complaint. Follow-up
+	// emails reply to it, so all rounds of a complaint read as one thread.
+	ThreadID string
+	// Results holds the outcomes of the other channels. It is only set for Reporters.
+	Results Results
+}
+
+// Receipt confirms that a channel accepted a message
+type Receipt struct {
+	// ID is the provider's id for the sent message, if it has one
+	ID     string    `json:"id,omitempty"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// Notifier sends messages through a single channel
+type Notifier interface {
+	Send(ctx context.Context, msg Message) (Receipt, error)
+}
+
+// Reporter is a Notifier that reports on the other channels of a dispatch. Reporters are
+// sent to after every other channel has finished, with the outcomes in Message.Results.
+type Reporter interface {
+	Notifier
+	ReportsResults()
+}
+
+// Recorder is a Notifier that only records messages for the operators of the service,
+// such as the service log. It does not reach a recipient.
+type Recorder interface {
+	Notifier
+	RecordsOnly()
+}
+
+// Registry maps channel names to notifiers
+type Registry struct {
+	mu        sync.RWMutex
+	notifiers map[string]Notifier
+}
+
+// NewRegistry creates a new empty registry
+func NewRegistry() *Registry {
+	return &Registry{notifiers: make(map[string]Notifier)}
+}
+
+// Register registers the notifier for a channel, replacing any previous one
+func (r *Registry) Register(channel string, n Notifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notifiers[channel] = n
+}
+
+// Get returns the notifier registered for a channel
+func (r *Registry) Get(channel string) (Notifier, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n, ok := r.notifiers[channel]
+	return n, ok
+}
+
+// Channels returns the names of all registered channels in sorted order
+func (r *Registry) Channels() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	channels := make([]string, 0, len(r.notifiers))
+	for channel := range r.notifiers {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// Result is the outcome of sending a message through one channel
+type Result struct {
+	Channel string
+	Receipt Receipt
+	Err     error
+	// Delivers is set for channels that reach a recipient, unlike Recorders and Reporters
+	Delivers bool
+}
+
+// Results are the per-channel outcomes of a dispatch
+type Results []Result
+
+// Succeeded reports whether at least one channel that reaches a recipient accepted the
+// message. Recorders and Reporters succeeding alone do not deliver it.
+func (rs Results) Succeeded() bool {
+	for _, r := range rs {
+		if r.Delivers && r.Err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the combined error of all failed channels, or nil if every channel succeeded
+func (rs Results) Err() error {
+	var errs []error
+	for _, r := range rs {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Channel, r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Dispatcher fans a message out to several channels
+type Dispatcher struct {
+	registry *Registry
+}
+
+// NewDispatcher creates a new dispatcher for the notifiers in the registry
+func NewDispatcher(registry *Registry) *Dispatcher {
+	return &Dispatcher{registry: registry}
+}
+
+// Dispatch sends the message through every channel concurrently and returns the
+// results in the order of channels. Reporters run once the other channels are done.
+func (d *Dispatcher) Dispatch(ctx context.Context, channels []string, msg Message) Results {
+	results := make(Results, len(channels))
+
+	reporters := make(map[int]Notifier)
+	var wg sync.WaitGroup
+	for i, channel := range channels {
+		results[i].Channel = channel
+
+		n, ok := d.registry.Get(channel)
+		if !ok {
+			results[i].Err = fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+			continue
+		}
+		if _, ok := n.(Reporter); ok {
+			reporters[i] = n
+			continue
+		}
+		_, records := n.(Recorder)
+		results[i].Delivers = !records
+
+		wg.Add(1)
+		go func(i int, n Notifier) {
+			defer wg.Done()
+			results[i].Receipt, results[i].Err = n.Send(ctx, msg)
+		}(i, n)
+	}
+	wg.Wait()
+
+	if len(reporters) == 0 {
+		return results
+	}
+
+	// Reporters see the results of every channel that is not a reporter
+	report := msg
+	for i, result := range results {
+		if _, ok := reporters[i]; !ok {
+			report.Results = append(report.Results, result)
+		}
+	}
+	for i, n := range reporters {
+		wg.Add(1)
+		go func(i int, n Notifier) {
+			defer wg.Done()
+			results[i].Receipt, results[i].Err = n.Send(ctx, report)
+		}(i, n)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// LogNotifier is a Notifier that writes messages to the service log
+type LogNotifier struct{}
+
+// RecordsOnly marks LogNotifier as a Recorder
+func (LogNotifier) RecordsOnly() {}
+
+// Send logs the message
+func (LogNotifier) Send(ctx context.Context, msg Message) (Receipt, error) {
+	log.Printf("Notification for complaint %s (attempt %d): %s: %s", msg.ComplaintID, msg.Attempt, msg.Subject, msg.Text)
+	return Receipt{SentAt: time.Now()}, nil
+}
@@ -0,0 +1,224 @@
+package notification
+
+import (
+	"complaint-escalator/internal/email"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fakeNotifier records the messages it is asked to send
+type fakeNotifier struct {
+	id       string
+	err      error
+	messages chan Message
+}
+
+func newFakeNotifier(id string, err error) *fakeNotifier {
+	return &fakeNotifier{id: id, err: err, messages: make(chan Message, 10)}
+}
+
+func (n *fakeNotifier) Send(ctx context.Context, msg Message) (Receipt, error) {
+	n.messages <- msg
+	if n.err != nil {
+		return Receipt{}, n.err
+	}
+	return Receipt{ID: n.id}, nil
+}
+
+// fakeEmailSender records the email messages it is asked to send
+type fakeEmailSender struct {
+	sent []email.EmailMessage
+}
+
+func (s *fakeEmailSender) SendEmail(ctx context.Context, msg email.EmailMessage) (string, error) {
+	s.sent = append(s.sent, msg)
+	return "op-1", nil
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("slack", newFakeNotifier("s", nil))
+	registry.Register("email", newFakeNotifier("e", nil))
+
+	if _, ok := registry.Get("email"); !ok {
+		t.Error("email notifier should be registered")
+	}
+	if _, ok := registry.Get("fax"); ok {
+		t.Error("fax notifier should not be registered")
+	}
+	if channels := registry.Channels(); !reflect.DeepEqual(channels, []string{"email", "slack"}) {
+		t.Errorf("Expected sorted channels, got %v", channels)
+	}
+}
+
+func TestDispatcherFansOut(t *testing.T) {
+	emailNotifier := newFakeNotifier("op-1", nil)
+	slackNotifier := newFakeNotifier("", errors.New("webhook unavailable"))
+
+	registry := NewRegistry()
+	registry.Register("email", emailNotifier)
+	registry.Register("slack", slackNotifier)
+	dispatcher := NewDispatcher(registry)
+
+	msg := Message{ComplaintID: "c1", Attempt: 2, Subject: "Subject", Text: "Text"}
+	results := dispatcher.Dispatch(context.Background(), []string{"email", "slack", "fax"}, msg)
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+
+	// Results follow the order of the channels
+	if results[0].Channel != "email" || results[0].Err != nil || results[0].Receipt.ID != "op-1" {
+		t.Errorf("Unexpected email result: %+v", results[0])
+	}
+	if results[1].Channel != "slack" || results[1].Err == nil {
+		t.Errorf("Unexpected slack result: %+v", results[1])
+	}
+	if results[2].Channel != "fax" || !errors.Is(results[2].Err, ErrUnknownChannel) {
+		t.Errorf("Unexpected fax result: %+v", results[2])
+	}
+
+	if got := <-emailNotifier.messages; !reflect.DeepEqual(got, msg) {
+		t.Errorf("Expected message %+v, got %+v", msg, got)
+	}
+
+	if !results.Succeeded() {
+		t.Error("Results should count as succeeded when one channel delivered")
+	}
+	if err := results.Err(); err == nil || !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("Expected aggregated error including the unknown channel, got %v", err)
+	}
+
+	// Nothing succeeded
+	results = dispatcher.Dispatch(context.Background(), []string{"slack"}, msg)
+	if results.Succeeded() {
+		t.Error("Results should not count as succeeded when every channel failed")
+	}
+
+	// Nothing failed
+	results = dispatcher.Dispatch(context.Background(), []string{"email"}, msg)
+	if err := results.Err(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	sender := &fakeEmailSender{}
+	notifier, err := NewEmailNotifier(sender, "from@example.com", "")
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	receipt, err := notifier.Send(context.Background(), Message{
+		Subject: "Subject",
+		Text:    "Text",
+		To:      []string{"to@example.com"},
+		CC:      []string{"cc@example.com"},
+		ReplyTo: "reply@example.com",
+	})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if receipt.ID != "op-1" {
+		t.Errorf("Expected receipt id op-1, got %s", receipt.ID)
+	}
+
+	if len(sender.sent) != 1 {
+		t.Fatalf("Expected 1 email, got %d", len(sender.sent))
+	}
+	sent := sender.sent[0]
+	if sent.From != "from@example.com" || sent.To[0] != "to@example.com" || sent.CC[0] != "cc@example.com" || sent.ReplyTo != "reply@example.com" || sent.Body != "Text" {
+		t.Errorf("Unexpected email: %+v", sent)
+	}
+	if sent.MessageID != "" || sent.InReplyTo != "" {
+		t.Errorf("Expected no thread headers without a thread id, got %+v", sent)
+	}
+}
+
+func TestEmailNotifierThreads(t *testing.T) {
+	sender := &fakeEmailSender{}
+	notifier, err := NewEmailNotifier(sender, "from@example.com", email.SubjectFollowUp)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	msg := Message{Subject: "Order #1234", Text: "Text", To: []string{"to@example.com"}, ThreadID: "<root@example.com>"}
+	for attempt := 1; attempt <= 3; attempt++ {
+		msg.Attempt = attempt
+		if _, err := notifier.Send(context.Background(), msg); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+	}
+
+	// The first round starts the thread
+	first := sender.sent[0]
+	if first.MessageID != "<root@example.com>" || first.InReplyTo != "" || first.Subject != "Order #1234" {
+		t.Errorf("Unexpected first email: %+v", first)
+	}
+
+	// Later rounds reply to it
+	third := sender.sent[2]
+	if third.MessageID != "" || third.InReplyTo != "<root@example.com>" || len(third.References) != 1 || third.References[0] != "<root@example.com>" {
+		t.Errorf("Unexpected thread headers: %+v", third)
+	}
+	if third.Subject != "[Follow-up #2] Order #1234" {
+		t.Errorf("Expected subject [Follow-up #2] Order #1234, got %s", third.Subject)
+	}
+
+	if _, err := NewEmailNotifier(sender, "from@example.com", "fwd"); err == nil {
+		t.Error("Expected error for unknown subject style")
+	}
+}
+
+// fakeReporter is a fake notifier that reports on the other channels
+type fakeReporter struct {
+	*fakeNotifier
+}
+
+func (fakeReporter) ReportsResults() {}
+
+func TestDispatcherRunsReportersLast(t *testing.T) {
+	reporter := fakeReporter{newFakeNotifier("r", nil)}
+
+	registry := NewRegistry()
+	registry.Register("email", newFakeNotifier("op-1", nil))
+	registry.Register("slack", newFakeNotifier("", errors.New("webhook unavailable")))
+	registry.Register("webhook", reporter)
+	dispatcher := NewDispatcher(registry)
+
+	results := dispatcher.Dispatch(context.Background(), []string{"webhook", "email", "slack"}, Message{ComplaintID: "c1"})
+	if results[0].Channel != "webhook" || results[0].Receipt.ID != "r" {
+		t.Errorf("Unexpected reporter result: %+v", results[0])
+	}
+
+	// The reporter sees every other channel, but not itself
+	report := <-reporter.messages
+	if len(report.Results) != 2 || report.Results[0].Channel != "email" || report.Results[1].Err == nil {
+		t.Errorf("Unexpected results passed to the reporter: %+v", report.Results)
+	}
+}
+
+func TestResultsSucceededOnlyCountsDelivery(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("email", newFakeNotifier("", errors.New("mailbox full")))
+	registry.Register("notification", LogNotifier{})
+	registry.Register("webhook", fakeReporter{newFakeNotifier("r", nil)})
+	dispatcher := NewDispatcher(registry)
+
+	// The log and the report succeeding do not deliver the message
+	results := dispatcher.Dispatch(context.Background(), []string{"email", "notification", "webhook"}, Message{ComplaintID: "c1"})
+	if results[1].Err != nil || results[2].Err != nil {
+		t.Fatalf("Expected the log and the reporter to succeed: %+v", results)
+	}
+	if results.Succeeded() {
+		t.Error("Results should not count as succeeded without a delivering channel")
+	}
+
+	registry.Register("email", newFakeNotifier("op-1", nil))
+	results = dispatcher.Dispatch(context.Background(), []string{"email", "notification", "webhook"}, Message{ComplaintID: "c1"})
+	if !results.Succeeded() || !results[0].Delivers || results[1].Delivers || results[2].Delivers {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
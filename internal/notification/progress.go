@@ -0,0 +1,72 @@
+package notification
+
+import "sync"
+
+// roundProgress remembers how far each target of a notifier got with the current round
+// of every complaint. The scheduler sends a failed round again, and the retry continues
+// where the failed attempt stopped, with the parts of the first attempt, so no chat or
+// recipient receives a part twice.
+type roundProgress[T any] struct {
+	mu     sync.Mutex
+	rounds map[string]*roundState[T]
+}
+
+// roundState is the progress of one round of a complaint
+type roundState[T any] struct {
+	attempt int
+	// parts are the messages of the round, sent in order to every target
+	parts []T
+
+	mu sync.Mutex
+	// sent counts the parts each target received
+	sent map[string]int
+}
+
+// round returns the progress of the round of msg. A new round starts with parts, while
+// a retried round keeps the parts of its first attempt. Messages without a complaint
+// always start a new round.
+func (p *roundProgress[T]) round(msg Message, parts []T) *roundState[T] {
+	fresh := &roundState[T]{attempt: msg.Attempt, parts: parts, sent: make(map[string]int)}
+	if msg.ComplaintID == "" {
+		return fresh
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if r, ok := p.rounds[msg.ComplaintID]; ok && r.attempt == msg.Attempt {
+		return r
+	}
+	if p.rounds == nil {
+		p.rounds = make(map[string]*roundState[T])
+	}
+	p.rounds[msg.ComplaintID] = fresh
+	return fresh
+}
+
+// finish forgets the round of msg once every target received all of it
+func (p *roundProgress[T]) finish(msg Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if r, ok := p.rounds[msg.ComplaintID]; ok && r.attempt == msg.Attempt {
+		delete(p.rounds, msg.ComplaintID)
+	}
+}
+
+// next returns the index of the next part target has to receive, or len(parts) when
+// it received all of them
+func (r *roundState[T]) next(target string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sent[target]
+}
+
+// delivered records that target received its next part
+func (r *roundState[T]) delivered(target string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sent[target]++
+}
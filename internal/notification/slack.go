@@ -0,0 +1,106 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// slackHeaderLimit is the maximum length of a Block Kit header text
+	slackHeaderLimit = 150
+	// slackSectionLimit is the maximum length of a Block Kit section text
+	slackSectionLimit = 3000
+)
+
+// slackEscaper escapes the characters Slack reserves for links and mentions
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// SlackNotifier is a Notifier that posts messages to a Slack incoming webhook
+type SlackNotifier struct {
+	webhookURL string
+	poster     httpPoster
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackPayload struct {
+	// Text is the fallback shown in notifications
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// NewSlackNotifier creates a new Slack notifier for the incoming webhook URL
+func NewSlackNotifier(webhookURL string) (*SlackNotifier, error) {
+	if webhookURL == "" {
+		return nil, fmt.Errorf("webhook URL cannot be empty")
+	}
+
+	return &SlackNotifier{
+		webhookURL: webhookURL,
+		poster:     newHTTPPoster(retryAfterHeader),
+	}, nil
+}
+
+// Send posts the message to the webhook
+func (n *SlackNotifier) Send(ctx context.Context, msg Message) (Receipt, error) {
+	payload, err := slackMessagePayload(msg)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("slack message: %w", err)
+	}
+	status, body, err := n.poster.postJSON(ctx, n.webhookURL, payload, nil)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("slack webhook failed: %w", err)
+	}
+	if status >= 400 {
+		return Receipt{}, fmt.Errorf("slack webhook failed with status %d: %s", status, string(body))
+	}
+	return Receipt{SentAt: time.Now()}, nil
+}
+
+// slackMessagePayload formats the message as Block Kit blocks: a header with the
+// subject, the text in sections and a context line identifying the complaint
+func slackMessagePayload(msg Message) (slackPayload, error) {
+	payload := slackPayload{
+		Text: slackEscaper.Replace(msg.Subject),
+	}
+
+	if msg.Subject != "" {
+		payload.Blocks = append(payload.Blocks, slackBlock{
+			Type: "header",
+			Text: &slackText{Type: "plain_text", Text: truncate(msg.Subject, slackHeaderLimit)},
+		})
+	}
+
+	chunks, err := splitText(slackEscaper.Replace(msg.Text), slackSectionLimit)
+	if err != nil {
+		return slackPayload{}, err
+	}
+	for _, chunk := range chunks {
+		payload.Blocks = append(payload.Blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: chunk},
+		})
+	}
+
+	if msg.ComplaintID != "" {
+		payload.Blocks = append(payload.Blocks, slackBlock{
+			Type: "context",
+			Elements: []slackText{{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf("Complaint `%s` · attempt %d", msg.ComplaintID, msg.Attempt),
+			}},
+		})
+	}
+	return payload, nil
+}
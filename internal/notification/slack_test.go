@@ -0,0 +1,192 @@
+package notification
+
+import (
+	"complaint-escalator/internal/backoff"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordWaits replaces the poster's wait so tests do not sleep, and records the requested waits
+func recordWaits(p *httpPoster) *[]time.Duration {
+	var waits []time.Duration
+	p.wait = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return &waits
+}
+
+func TestSlackNotifierSend(t *testing.T) {
+	var payload slackPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected JSON content type, got %s", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	notifier, err := NewSlackNotifier(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	_, err = notifier.Send(context.Background(), Message{
+		ComplaintID: "late-delivery",
+		Attempt:     3,
+		Subject:     "Order #1234",
+		Text:        "Where is <my> order & refund?",
+	})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	if len(payload.Blocks) != 3 {
+		t.Fatalf("Expected header, section and context blocks, got %+v", payload.Blocks)
+	}
+	if payload.Blocks[0].Type != "header" || payload.Blocks[0].Text.Text != "Order #1234" {
+		t.Errorf("Unexpected header block: %+v", payload.Blocks[0])
+	}
+	if payload.Blocks[1].Type != "section" || payload.Blocks[1].Text.Text != "Where is &lt;my&gt; order &amp; refund?" {
+		t.Errorf("Unexpected section block: %+v", payload.Blocks[1])
+	}
+	if payload.Blocks[2].Type != "context" || !strings.Contains(payload.Blocks[2].Elements[0].Text, "attempt 3") {
+		t.Errorf("Unexpected context block: %+v", payload.Blocks[2])
+	}
+	if payload.Text != "Order #1234" {
+		t.Errorf("Expected fallback text to be the subject, got %s", payload.Text)
+	}
+}
+
+func TestSlackNotifierSplitsLongText(t *testing.T) {
+	payload, err := slackMessagePayload(Message{Text: strings.Repeat("word ", 1000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sections := 0
+	for _, block := range payload.Blocks {
+		if block.Type != "section" {
+			continue
+		}
+		sections++
+		if len(block.Text.Text) > slackSectionLimit {
+			t.Errorf("Section of length %d exceeds the limit", len(block.Text.Text))
+		}
+	}
+	if sections != 2 {
+		t.Errorf("Expected 2 sections, got %d", sections)
+	}
+}
+
+func TestSlackNotifierRateLimit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	notifier, err := NewSlackNotifier(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	waits := recordWaits(&notifier.poster)
+
+	if _, err := notifier.Send(context.Background(), Message{Subject: "Subject", Text: "Text"}); err != nil {
+		t.Fatalf("Expected send to succeed after the rate limit: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests)
+	}
+	if len(*waits) != 1 || (*waits)[0] != 7*time.Second {
+		t.Errorf("Expected one wait of 7s, got %v", *waits)
+	}
+}
+
+func TestSlackNotifierErrors(t *testing.T) {
+	if _, err := NewSlackNotifier(""); err == nil {
+		t.Error("Expected error for empty webhook URL")
+	}
+
+	// Rate limited on every request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	notifier, _ := NewSlackNotifier(server.URL)
+	recordWaits(&notifier.poster)
+
+	_, err := notifier.Send(context.Background(), Message{Text: "Text"})
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter != time.Second {
+		t.Errorf("Expected rate limit error, got %v", err)
+	}
+
+	// Rejected payload
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, "invalid_blocks")
+	}))
+	defer server.Close()
+
+	notifier, _ = NewSlackNotifier(server.URL)
+	if _, err := notifier.Send(context.Background(), Message{Text: "Text"}); err == nil || !strings.Contains(err.Error(), "invalid_blocks") {
+		t.Errorf("Expected error with the Slack reason, got %v", err)
+	}
+}
+
+func TestHTTPPosterBacksOffWithoutRetryAfter(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	notifier, _ := NewSlackNotifier(server.URL)
+	notifier.poster.backoff = backoff.Policy{Strategy: backoff.StrategyExponential, Initial: time.Second, MaxAttempts: 4}
+	waits := recordWaits(&notifier.poster)
+
+	_, err := notifier.Send(context.Background(), Message{Text: "Text"})
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("Expected rate limit error, got %v", err)
+	}
+
+	// Waits grow until the policy runs out of attempts
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	if !reflect.DeepEqual(*waits, expected) {
+		t.Errorf("Expected waits %v, got %v", expected, *waits)
+	}
+	if requests != 4 {
+		t.Errorf("Expected 4 requests, got %d", requests)
+	}
+
+	// The default policy keeps jittered waits within its bounds
+	for attempt, previous := 1, time.Duration(0); attempt < 4; attempt++ {
+		delay := rateLimitBackoff.Delay(attempt, previous)
+		if delay < time.Second || delay > maxRetryAfter {
+			t.Errorf("Default wait %v out of bounds", delay)
+		}
+		previous = delay
+	}
+}
@@ -0,0 +1,105 @@
+package notification
+
+import (
+	"complaint-escalator/internal/sms"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultMaxSegments is the segment budget of one SMS when none is configured
+const defaultMaxSegments = 3
+
+// SMSSender sends SMS messages and reports the outcome per recipient
+type SMSSender interface {
+	SendSMS(ctx context.Context, msg sms.SMSMessage) ([]sms.SendResult, error)
+}
+
+// SMSNotifier is a Notifier that sends messages as SMS to fixed phone numbers
+type SMSNotifier struct {
+	sender      SMSSender
+	from        string
+	to          []string
+	maxSegments int
+	truncate    bool
+	// progress lets a retried round skip the recipients that already received it
+	progress roundProgress[string]
+}
+
+// NewSMSNotifier creates a new SMS notifier that sends from one E.164 number to others.
+// Texts longer than maxSegments segments are truncated when truncate is set, and
+// otherwise split into several messages.
+func NewSMSNotifier(sender SMSSender, from string, to []string, maxSegments int, truncate bool) (*SMSNotifier, error) {
+	if err := sms.ValidateNumber(from); err != nil {
+		return nil, fmt.Errorf("sender: %w", err)
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+	for _, number := range to {
+		if err := sms.ValidateNumber(number); err != nil {
+			return nil, fmt.Errorf("recipient: %w", err)
+		}
+	}
+	if maxSegments < 1 {
+		maxSegments = defaultMaxSegments
+	}
+
+	return &SMSNotifier{
+		sender:      sender,
+		from:        from,
+		to:          to,
+		maxSegments: maxSegments,
+		truncate:    truncate,
+	}, nil
+}
+
+// Send sends the message text to every recipient. The receipt id lists the sent
+// message ids as number:message pairs; recipients that were rejected are reported
+// in the error and get no further parts. When the round is retried, only the parts
+// each recipient is missing are sent.
+func (n *SMSNotifier) Send(ctx context.Context, msg Message) (Receipt, error) {
+	var parts []string
+	if n.truncate {
+		parts = []string{sms.Truncate(msg.Text, n.maxSegments)}
+	} else {
+		parts = sms.Split(msg.Text, n.maxSegments)
+	}
+	round := n.progress.round(msg, parts)
+
+	var ids []string
+	var errs []error
+	for i, part := range round.parts {
+		// Only recipients that received every earlier part get this one
+		var to []string
+		for _, number := range n.to {
+			if round.next(number) == i {
+				to = append(to, number)
+			}
+		}
+		if len(to) == 0 {
+			continue
+		}
+
+		results, err := n.sender.SendSMS(ctx, sms.SMSMessage{From: n.from, To: to, Message: part})
+		if err != nil {
+			return Receipt{ID: strings.Join(ids, ",")}, err
+		}
+		for _, result := range results {
+			if !result.Successful {
+				errs = append(errs, fmt.Errorf("%s: status %d: %s", result.To, result.HTTPStatusCode, result.ErrorMessage))
+				continue
+			}
+			round.delivered(result.To)
+			ids = append(ids, fmt.Sprintf("%s:%s", result.To, result.MessageID))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return Receipt{ID: strings.Join(ids, ",")}, fmt.Errorf("SMS rejected for some recipients: %w", err)
+	}
+	n.progress.finish(msg)
+	return Receipt{ID: strings.Join(ids, ","), SentAt: time.Now()}, nil
+}
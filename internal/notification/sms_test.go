@@ -0,0 +1,126 @@
+package notification
+
+import (
+	"complaint-escalator/internal/sms"
+	"context"
+	"strings"
+	"testing"
+)
+
+// fakeSMSSender records the SMS it is asked to send and rejects the numbers in reject
+type fakeSMSSender struct {
+	sent   []sms.SMSMessage
+	reject map[string]bool
+}
+
+func (s *fakeSMSSender) SendSMS(ctx context.Context, msg sms.SMSMessage) ([]sms.SendResult, error) {
+	s.sent = append(s.sent, msg)
+
+	results := make([]sms.SendResult, len(msg.To))
+	for i, to := range msg.To {
+		if s.reject[to] {
+			results[i] = sms.SendResult{To: to, HTTPStatusCode: 400, ErrorMessage: "Invalid To phone number format."}
+			continue
+		}
+		results[i] = sms.SendResult{To: to, MessageID: "msg", HTTPStatusCode: 202, Successful: true}
+	}
+	return results, nil
+}
+
+func TestSMSNotifierSplitsLongText(t *testing.T) {
+	sender := &fakeSMSSender{}
+	notifier, err := NewSMSNotifier(sender, "+18335550100", []string{"+6591234567"}, 1, false)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	receipt, err := notifier.Send(context.Background(), Message{Text: strings.Repeat("Please refund order 1234. ", 10)})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if len(sender.sent) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(sender.sent))
+	}
+	for _, msg := range sender.sent {
+		if segments, _ := sms.Segments(msg.Message); segments != 1 {
+			t.Errorf("Expected single segment messages, got %d segments", segments)
+		}
+	}
+	if receipt.ID != "+6591234567:msg,+6591234567:msg" {
+		t.Errorf("Unexpected receipt id %s", receipt.ID)
+	}
+}
+
+func TestSMSNotifierTruncatesLongText(t *testing.T) {
+	sender := &fakeSMSSender{}
+	notifier, err := NewSMSNotifier(sender, "+18335550100", []string{"+6591234567"}, 1, true)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	if _, err := notifier.Send(context.Background(), Message{Text: strings.Repeat("Please refund order 1234. ", 10)}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if len(sender.sent) != 1 || !strings.HasSuffix(sender.sent[0].Message, "...") {
+		t.Errorf("Expected one truncated message, got %+v", sender.sent)
+	}
+}
+
+func TestSMSNotifierRecipientResults(t *testing.T) {
+	sender := &fakeSMSSender{reject: map[string]bool{"+6598765432": true}}
+	notifier, err := NewSMSNotifier(sender, "+18335550100", []string{"+6591234567", "+6598765432"}, 0, false)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	receipt, err := notifier.Send(context.Background(), Message{Text: "Where is my order?"})
+	if err == nil || !strings.Contains(err.Error(), "+6598765432") {
+		t.Errorf("Expected error naming the rejected recipient, got %v", err)
+	}
+	if receipt.ID != "+6591234567:msg" {
+		t.Errorf("Expected the delivered recipient in the receipt, got %s", receipt.ID)
+	}
+}
+
+func TestNewSMSNotifierValidatesNumbers(t *testing.T) {
+	if _, err := NewSMSNotifier(&fakeSMSSender{}, "18335550100", []string{"+6591234567"}, 0, false); err == nil {
+		t.Error("Expected error for sender without country code")
+	}
+	if _, err := NewSMSNotifier(&fakeSMSSender{}, "+18335550100", []string{"9123 4567"}, 0, false); err == nil {
+		t.Error("Expected error for invalid recipient")
+	}
+	if _, err := NewSMSNotifier(&fakeSMSSender{}, "+18335550100", nil, 0, false); err == nil {
+		t.Error("Expected error without recipients")
+	}
+}
+
+func TestSMSNotifierRetriesOnlyRejectedRecipients(t *testing.T) {
+	sender := &fakeSMSSender{reject: map[string]bool{"+6591234568": true}}
+	notifier, err := NewSMSNotifier(sender, "+18335550100", []string{"+6591234567", "+6591234568"}, 1, false)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	msg := Message{ComplaintID: "c1", Attempt: 1, Text: strings.Repeat("Please refund order 1234. ", 10)}
+	if _, err := notifier.Send(context.Background(), msg); err == nil {
+		t.Fatal("Expected an error for the rejected recipient")
+	}
+	// The rejected recipient gets no second part without the first
+	if len(sender.sent) != 2 || len(sender.sent[1].To) != 1 || sender.sent[1].To[0] != "+6591234567" {
+		t.Fatalf("Unexpected messages %+v", sender.sent)
+	}
+
+	sender.reject = nil
+	receipt, err := notifier.Send(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Failed to retry: %v", err)
+	}
+	for _, sent := range sender.sent[2:] {
+		if len(sent.To) != 1 || sent.To[0] != "+6591234568" {
+			t.Errorf("Expected the retry to go to the rejected recipient only, got %v", sent.To)
+		}
+	}
+	if len(sender.sent) != 4 || receipt.ID != "+6591234568:msg,+6591234568:msg" {
+		t.Errorf("Expected both parts for the rejected recipient, got %d messages and receipt %s", len(sender.sent), receipt.ID)
+	}
+}
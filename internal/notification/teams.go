@@ -0,0 +1,114 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// adaptiveCardContentType is the attachment content type of an Adaptive Card
+const adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+
+// TeamsNotifier is a Notifier that posts Adaptive Cards to a Microsoft Teams incoming webhook
+type TeamsNotifier struct {
+	webhookURL string
+	poster     httpPoster
+}
+
+type teamsPayload struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     adaptiveCard `json:"content"`
+}
+
+type adaptiveCard struct {
+	Schema  string                `json:"$schema"`
+	Type    string                `json:"type"`
+	Version string                `json:"version"`
+	Body    []adaptiveCardElement `json:"body"`
+}
+
+type adaptiveCardElement struct {
+	Type   string             `json:"type"`
+	Text   string             `json:"text,omitempty"`
+	Weight string             `json:"weight,omitempty"`
+	Size   string             `json:"size,omitempty"`
+	Wrap   bool               `json:"wrap,omitempty"`
+	Facts  []adaptiveCardFact `json:"facts,omitempty"`
+}
+
+type adaptiveCardFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// NewTeamsNotifier creates a new Teams notifier for the incoming webhook URL
+func NewTeamsNotifier(webhookURL string) (*TeamsNotifier, error) {
+	if webhookURL == "" {
+		return nil, fmt.Errorf("webhook URL cannot be empty")
+	}
+
+	return &TeamsNotifier{
+		webhookURL: webhookURL,
+		poster:     newHTTPPoster(retryAfterHeader),
+	}, nil
+}
+
+// Send posts the message as an Adaptive Card
+func (n *TeamsNotifier) Send(ctx context.Context, msg Message) (Receipt, error) {
+	status, body, err := n.poster.postJSON(ctx, n.webhookURL, teamsMessagePayload(msg), nil)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("teams webhook failed: %w", err)
+	}
+	if status >= 300 {
+		return Receipt{}, fmt.Errorf("teams webhook failed with status %d: %s", status, string(body))
+	}
+	return Receipt{SentAt: time.Now()}, nil
+}
+
+// teamsMessagePayload formats the message as an Adaptive Card with the subject as
+// heading, the text, and the complaint id and attempt as facts
+func teamsMessagePayload(msg Message) teamsPayload {
+	card := adaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+	}
+
+	if msg.Subject != "" {
+		card.Body = append(card.Body, adaptiveCardElement{
+			Type:   "TextBlock",
+			Text:   msg.Subject,
+			Weight: "Bolder",
+			Size:   "Medium",
+			Wrap:   true,
+		})
+	}
+	card.Body = append(card.Body, adaptiveCardElement{
+		Type: "TextBlock",
+		Text: msg.Text,
+		Wrap: true,
+	})
+	if msg.ComplaintID != "" {
+		card.Body = append(card.Body, adaptiveCardElement{
+			Type: "FactSet",
+			Facts: []adaptiveCardFact{
+				{Title: "Complaint", Value: msg.ComplaintID},
+				{Title: "Attempt", Value: strconv.Itoa(msg.Attempt)},
+			},
+		})
+	}
+
+	return teamsPayload{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: adaptiveCardContentType,
+			Content:     card,
+		}},
+	}
+}
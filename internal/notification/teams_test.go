@@ -0,0 +1,88 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTeamsNotifierSend(t *testing.T) {
+	var payload teamsPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	notifier, err := NewTeamsNotifier(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	receipt, err := notifier.Send(context.Background(), Message{
+		ComplaintID: "late-delivery",
+		Attempt:     4,
+		Subject:     "Order #1234",
+		Text:        "Where is my order?",
+	})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if receipt.SentAt.IsZero() {
+		t.Error("Receipt should carry the send time")
+	}
+
+	if payload.Type != "message" || len(payload.Attachments) != 1 || payload.Attachments[0].ContentType != adaptiveCardContentType {
+		t.Fatalf("Unexpected payload: %+v", payload)
+	}
+	card := payload.Attachments[0].Content
+	if card.Type != "AdaptiveCard" || len(card.Body) != 3 {
+		t.Fatalf("Unexpected card: %+v", card)
+	}
+	if card.Body[0].Text != "Order #1234" || card.Body[0].Weight != "Bolder" {
+		t.Errorf("Unexpected heading: %+v", card.Body[0])
+	}
+	if card.Body[1].Text != "Where is my order?" || !card.Body[1].Wrap {
+		t.Errorf("Unexpected text: %+v", card.Body[1])
+	}
+	if facts := card.Body[2].Facts; len(facts) != 2 || facts[0].Value != "late-delivery" || facts[1].Value != "4" {
+		t.Errorf("Unexpected facts: %+v", card.Body[2])
+	}
+}
+
+func TestTeamsNotifierErrors(t *testing.T) {
+	if _, err := NewTeamsNotifier(""); err == nil {
+		t.Error("Expected error for empty webhook URL")
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, "Webhook message delivery failed with error: Microsoft Teams endpoint returned HttpStatusCode BadRequest")
+	}))
+	defer server.Close()
+
+	notifier, _ := NewTeamsNotifier(server.URL)
+	waits := recordWaits(&notifier.poster)
+
+	_, err := notifier.Send(context.Background(), Message{Text: "Text"})
+	if err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Errorf("Expected error with the status, got %v", err)
+	}
+	if len(*waits) != 1 || (*waits)[0] != 2*time.Second {
+		t.Errorf("Expected one wait of 2s, got %v", *waits)
+	}
+}
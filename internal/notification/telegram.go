@@ -0,0 +1,182 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// defaultTelegramAPIURL is the Telegram Bot API endpoint
+	defaultTelegramAPIURL = "https://api.telegram.org"
+	// telegramMessageLimit is the maximum length of a Telegram message
+	telegramMessageLimit = 4096
+	// telegramSubjectLimit is the maximum length of the subject heading the first message,
+	// which leaves most of the message to the text
+	telegramSubjectLimit = 256
+)
+
+// telegramEscaper escapes the characters that are reserved in MarkdownV2
+var telegramEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// EscapeMarkdownV2 escapes text for use in a Telegram MarkdownV2 message
+func EscapeMarkdownV2(text string) string {
+	return telegramEscaper.Replace(text)
+}
+
+// TelegramNotifier is a Notifier that sends messages to Telegram chats through the Bot API
+type TelegramNotifier struct {
+	apiURL  string
+	token   string
+	chatIDs []string
+	poster  httpPoster
+	// progress lets a retried round skip the chats that already received it
+	progress roundProgress[string]
+}
+
+type telegramSendMessageRequest struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"`
+}
+
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+	Result      struct {
+		MessageID int64 `json:"message_id"`
+	} `json:"result"`
+	Parameters struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// NewTelegramNotifier creates a new Telegram notifier for the bot token that sends to
+// every chat id. An empty apiURL uses the public Bot API.
+func NewTelegramNotifier(apiURL, token string, chatIDs []string) (*TelegramNotifier, error) {
+	if token == "" {
+		return nil, fmt.Errorf("bot token cannot be empty")
+	}
+	if len(chatIDs) == 0 {
+		return nil, fmt.Errorf("at least one chat id is required")
+	}
+	if apiURL == "" {
+		apiURL = defaultTelegramAPIURL
+	}
+
+	return &TelegramNotifier{
+		apiURL:  strings.TrimSuffix(apiURL, "/"),
+		token:   token,
+		chatIDs: chatIDs,
+		poster:  newHTTPPoster(telegramRetryAfter),
+	}, nil
+}
+
+// Send sends the message to every chat, split into several messages when it is too long.
+// The receipt id lists the sent message ids as chat:message pairs. When the round is
+// retried after some chats failed, only the parts those chats are missing are sent.
+func (n *TelegramNotifier) Send(ctx context.Context, msg Message) (Receipt, error) {
+	parts, err := telegramMessageParts(msg)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("telegram message: %w", err)
+	}
+	round := n.progress.round(msg, parts)
+
+	var ids []string
+	var errs []error
+	for _, chatID := range n.chatIDs {
+		for i := round.next(chatID); i < len(round.parts); i++ {
+			messageID, err := n.sendMessage(ctx, chatID, round.parts[i])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("chat %s: %w", chatID, err))
+				break
+			}
+			round.delivered(chatID)
+			ids = append(ids, fmt.Sprintf("%s:%d", chatID, messageID))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return Receipt{ID: strings.Join(ids, ",")}, fmt.Errorf("telegram send failed: %w", err)
+	}
+	n.progress.finish(msg)
+	return Receipt{ID: strings.Join(ids, ","), SentAt: time.Now()}, nil
+}
+
+// sendMessage sends one MarkdownV2 message and returns its message id
+func (n *TelegramNotifier) sendMessage(ctx context.Context, chatID, text string) (int64, error) {
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", n.apiURL, n.token)
+	request := telegramSendMessageRequest{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: "MarkdownV2",
+	}
+
+	status, body, err := n.poster.postJSON(ctx, endpoint, request, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var resp telegramResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, fmt.Errorf("failed to decode response with status %d: %w", status, err)
+	}
+	if !resp.OK {
+		return 0, fmt.Errorf("status %d: %s", status, resp.Description)
+	}
+	return resp.Result.MessageID, nil
+}
+
+// telegramMessageParts formats the message as MarkdownV2 messages: the subject in bold,
+// the text, and an italic line identifying the complaint. Text that does not fit into one
+// message is split, with the subject on the first part and the complaint line on the last.
+// Long subjects are truncated.
+func telegramMessageParts(msg Message) ([]string, error) {
+	subject := truncate(msg.Subject, telegramSubjectLimit)
+	var header, footer string
+	if subject != "" {
+		header = subject + "\n\n"
+	}
+	if msg.ComplaintID != "" {
+		footer = fmt.Sprintf("Complaint %s · attempt %d", msg.ComplaintID, msg.Attempt)
+	}
+
+	// The length limit applies to the text after MarkdownV2 parsing, so the
+	// escape characters do not count. Two more characters go to the line break before the
+	// footer. The limit stays positive even next to a very long complaint id.
+	limit := telegramMessageLimit - utf8.RuneCountInString(header) - utf8.RuneCountInString(footer) - 2
+	chunks, err := splitText(msg.Text, max(limit, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		parts[i] = EscapeMarkdownV2(chunk)
+	}
+	if header != "" {
+		parts[0] = "*" + EscapeMarkdownV2(subject) + "*\n\n" + parts[0]
+	}
+	if footer != "" {
+		parts[len(parts)-1] += "\n\n_" + EscapeMarkdownV2(footer) + "_"
+	}
+	return parts, nil
+}
+
+// telegramRetryAfter reads the wait from the retry_after parameter of a 429 reply
+func telegramRetryAfter(resp *http.Response, body []byte) time.Duration {
+	var reply telegramResponse
+	if err := json.Unmarshal(body, &reply); err == nil && reply.Parameters.RetryAfter > 0 {
+		return time.Duration(reply.Parameters.RetryAfter) * time.Second
+	}
+	return retryAfterHeader(resp, body)
+}
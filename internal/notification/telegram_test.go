@@ -0,0 +1,272 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// fakeTelegramAPI is a local stand-in for the Telegram Bot API
+type fakeTelegramAPI struct {
+	mu       sync.Mutex
+	requests []telegramSendMessageRequest
+	// reply answers a request, or nil to accept it
+	reply func(w http.ResponseWriter, req telegramSendMessageRequest) bool
+}
+
+func newFakeTelegramAPI(t *testing.T, token string) (*fakeTelegramAPI, *httptest.Server) {
+	t.Helper()
+
+	api := &fakeTelegramAPI{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bot"+token+"/sendMessage" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"ok":false,"error_code":404,"description":"Not Found"}`)
+			return
+		}
+
+		var req telegramSendMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+
+		api.mu.Lock()
+		api.requests = append(api.requests, req)
+		id := len(api.requests)
+		reply := api.reply
+		api.mu.Unlock()
+
+		if reply != nil && reply(w, req) {
+			return
+		}
+		fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d}}`, id)
+	}))
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+func TestTelegramNotifierSend(t *testing.T) {
+	api, server := newFakeTelegramAPI(t, "123:abc")
+
+	notifier, err := NewTelegramNotifier(server.URL, "123:abc", []string{"42", "@escalations"})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	receipt, err := notifier.Send(context.Background(), Message{
+		ComplaintID: "late-delivery",
+		Attempt:     2,
+		Subject:     "Order #1234",
+		Text:        "Where is my order (placed 2025-01-02)?",
+	})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if receipt.ID != "42:1,@escalations:2" {
+		t.Errorf("Expected message ids in the receipt, got %s", receipt.ID)
+	}
+
+	if len(api.requests) != 2 {
+		t.Fatalf("Expected one message per chat, got %d", len(api.requests))
+	}
+	req := api.requests[0]
+	if req.ChatID != "42" || req.ParseMode != "MarkdownV2" {
+		t.Errorf("Unexpected request: %+v", req)
+	}
+	expected := "*Order \\#1234*\n\nWhere is my order \\(placed 2025\\-01\\-02\\)?\n\n_Complaint late\\-delivery · attempt 2_"
+	if req.Text != expected {
+		t.Errorf("Expected text %q, got %q", expected, req.Text)
+	}
+}
+
+func TestTelegramNotifierSplitsLongMessages(t *testing.T) {
+	api, server := newFakeTelegramAPI(t, "token")
+
+	notifier, err := NewTelegramNotifier(server.URL, "token", []string{"42"})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	text := strings.Repeat("Please refund my order.\n", 400)
+	if _, err := notifier.Send(context.Background(), Message{ComplaintID: "c1", Attempt: 1, Subject: "Refund", Text: text}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	if len(api.requests) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(api.requests))
+	}
+	for i, req := range api.requests {
+		// Escape characters do not count towards the limit
+		if n := utf8.RuneCountInString(strings.ReplaceAll(req.Text, `\`, "")); n > telegramMessageLimit {
+			t.Errorf("Message %d has %d characters", i, n)
+		}
+	}
+	if !strings.HasPrefix(api.requests[0].Text, "*Refund*") || strings.HasPrefix(api.requests[1].Text, "*Refund*") {
+		t.Error("Only the first message should carry the subject")
+	}
+	if !strings.HasSuffix(api.requests[2].Text, "attempt 1_") || strings.HasSuffix(api.requests[0].Text, "attempt 1_") {
+		t.Error("Only the last message should carry the complaint line")
+	}
+}
+
+func TestTelegramNotifierTruncatesLongSubjects(t *testing.T) {
+	api, server := newFakeTelegramAPI(t, "token")
+
+	notifier, err := NewTelegramNotifier(server.URL, "token", []string{"42"})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	msg := Message{ComplaintID: "c1", Attempt: 1, Subject: strings.Repeat("Refund ", 700), Text: "Please refund my order."}
+	if _, err := notifier.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	if len(api.requests) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(api.requests))
+	}
+	text := api.requests[0].Text
+	if n := utf8.RuneCountInString(strings.ReplaceAll(text, `\`, "")); n > telegramMessageLimit {
+		t.Errorf("Message has %d characters", n)
+	}
+	if !strings.Contains(text, "…*") || !strings.Contains(text, `Please refund my order\.`) {
+		t.Errorf("Expected a truncated subject followed by the text, got %q", text)
+	}
+}
+
+func TestTelegramNotifierRetryAfter(t *testing.T) {
+	api, server := newFakeTelegramAPI(t, "token")
+	limited := false
+	api.reply = func(w http.ResponseWriter, req telegramSendMessageRequest) bool {
+		if limited {
+			return false
+		}
+		limited = true
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 5","parameters":{"retry_after":5}}`)
+		return true
+	}
+
+	notifier, err := NewTelegramNotifier(server.URL, "token", []string{"42"})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	waits := recordWaits(&notifier.poster)
+
+	if _, err := notifier.Send(context.Background(), Message{Text: "Text"}); err != nil {
+		t.Fatalf("Expected send to succeed after the rate limit: %v", err)
+	}
+	if len(*waits) != 1 || (*waits)[0] != 5*time.Second {
+		t.Errorf("Expected one wait of 5s, got %v", *waits)
+	}
+	if len(api.requests) != 2 {
+		t.Errorf("Expected the message to be sent again, got %d requests", len(api.requests))
+	}
+}
+
+func TestTelegramNotifierErrors(t *testing.T) {
+	if _, err := NewTelegramNotifier("", "", []string{"42"}); err == nil {
+		t.Error("Expected error for empty token")
+	}
+	if _, err := NewTelegramNotifier("", "token", nil); err == nil {
+		t.Error("Expected error without chat ids")
+	}
+
+	// One chat rejects the message
+	api, server := newFakeTelegramAPI(t, "token")
+	api.reply = func(w http.ResponseWriter, req telegramSendMessageRequest) bool {
+		if req.ChatID != "blocked" {
+			return false
+		}
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`)
+		return true
+	}
+
+	notifier, err := NewTelegramNotifier(server.URL, "token", []string{"blocked", "42"})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	receipt, err := notifier.Send(context.Background(), Message{Text: "Text"})
+	if err == nil || !strings.Contains(err.Error(), "bot was blocked") {
+		t.Errorf("Expected error with the Telegram description, got %v", err)
+	}
+	if receipt.ID != "42:2" {
+		t.Errorf("Expected the delivered message in the receipt, got %s", receipt.ID)
+	}
+}
+
+func TestEscapeMarkdownV2(t *testing.T) {
+	if got := EscapeMarkdownV2(`a_b*c[d](e)~f` + "`" + `>#+-=|{}.!\`); got != `a\_b\*c\[d\]\(e\)\~f`+"\\`"+`\>\#\+\-\=\|\{\}\.\!\\` {
+		t.Errorf("Unexpected escaping: %s", got)
+	}
+}
+
+func TestTelegramNotifierHidesTokenInErrors(t *testing.T) {
+	_, server := newFakeTelegramAPI(t, "123:secret")
+	server.Close()
+
+	notifier, err := NewTelegramNotifier(server.URL, "123:secret", []string{"42"})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	_, err = notifier.Send(context.Background(), Message{Text: "Hello"})
+	if err == nil {
+		t.Fatal("Expected an error for an unreachable API")
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("Error reveals the bot token: %v", err)
+	}
+}
+
+func TestTelegramNotifierRetriesOnlyFailedChats(t *testing.T) {
+	api, server := newFakeTelegramAPI(t, "token")
+	blocked := true
+	api.reply = func(w http.ResponseWriter, req telegramSendMessageRequest) bool {
+		if req.ChatID != "flaky" || !blocked {
+			return false
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, `{"ok":false,"error_code":502,"description":"Bad Gateway"}`)
+		return true
+	}
+
+	notifier, err := NewTelegramNotifier(server.URL, "token", []string{"42", "flaky"})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	msg := Message{ComplaintID: "c1", Attempt: 1, Text: "First wording"}
+	if _, err := notifier.Send(context.Background(), msg); err == nil {
+		t.Fatal("Expected an error for the failing chat")
+	}
+
+	// The retried round goes to the failed chat only, with the wording of the first attempt
+	blocked = false
+	msg.Text = "Second wording"
+	receipt, err := notifier.Send(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Failed to retry: %v", err)
+	}
+	if len(api.requests) != 3 || api.requests[2].ChatID != "flaky" || !strings.Contains(api.requests[2].Text, "First wording") {
+		t.Errorf("Expected one more message to the failed chat, got %+v", api.requests)
+	}
+	if receipt.ID != "flaky:3" {
+		t.Errorf("Unexpected receipt id %s", receipt.ID)
+	}
+
+	// The next round goes to every chat again
+	if _, err := notifier.Send(context.Background(), Message{ComplaintID: "c1", Attempt: 2, Text: "Round two"}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if len(api.requests) != 5 {
+		t.Errorf("Expected the next round to reach both chats, got %d requests", len(api.requests))
+	}
+}
@@ -0,0 +1,52 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// truncate shortens text to at most limit characters, marking the cut with an ellipsis
+func truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:limit-1]) + "…"
+}
+
+// splitText splits text into chunks of at most limit characters. Chunks end at
+// the last line break or space before the limit where possible.
+func splitText(text string, limit int) ([]string, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("text limit must be at least 1, got %d", limit)
+	}
+
+	var chunks []string
+	runes := []rune(text)
+	for len(runes) > limit {
+		cut := limit
+		if i := lastIndexFunc(runes[:limit], func(r rune) bool { return r == '\n' }); i > 0 {
+			cut = i + 1
+		} else if i := lastIndexFunc(runes[:limit], unicode.IsSpace); i > 0 {
+			cut = i + 1
+		}
+		chunks = append(chunks, strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace))
+		runes = runes[cut:]
+	}
+	if rest := strings.TrimRightFunc(string(runes), unicode.IsSpace); rest != "" || len(chunks) == 0 {
+		chunks = append(chunks, rest)
+	}
+	return chunks, nil
+}
+
+// lastIndexFunc returns the index of the last rune satisfying f, or -1
+func lastIndexFunc(runes []rune, f func(rune) bool) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if f(runes[i]) {
+			return i
+		}
+	}
+	return -1
+}
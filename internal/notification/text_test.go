@@ -0,0 +1,51 @@
+package notification
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		expected []string
+	}{
+		{"short", "hello", 10, []string{"hello"}},
+		{"empty", "", 10, []string{""}},
+		{"at line break", "first line\nsecond line", 15, []string{"first line", "second line"}},
+		{"at space", "one two three", 8, []string{"one two", "three"}},
+		{"hard cut", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"multibyte", "äöüäöü", 3, []string{"äöü", "äöü"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitText(tt.text, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("splitText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitTextRejectsLimitBelowOne(t *testing.T) {
+	for _, limit := range []int{0, -5} {
+		if _, err := splitText("text", limit); err == nil {
+			t.Errorf("Expected error for limit %d", limit)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("Expected unchanged text, got %s", got)
+	}
+	if got := truncate("too long text", 5); got != "too …" {
+		t.Errorf("Expected truncated text, got %s", got)
+	}
+}
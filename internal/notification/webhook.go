@@ -0,0 +1,175 @@
+package notification
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// WebhookSignatureHeader carries the signature of a webhook request as t=<unix time>,v1=<hex HMAC>
+	WebhookSignatureHeader = "X-Escalator-Signature"
+	// WebhookEventHeader names the event a webhook request reports
+	WebhookEventHeader = "X-Escalator-Event"
+	// WebhookDeliveryHeader identifies a delivery so receivers can drop duplicates
+	WebhookDeliveryHeader = "X-Escalator-Delivery"
+
+	// webhookEscalatedEvent is the event sent when a complaint is escalated
+	webhookEscalatedEvent = "complaint.escalated"
+)
+
+// ErrInvalidSignature is returned when a webhook signature does not verify
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// WebhookPayload is the JSON body posted to webhook URLs
+type WebhookPayload struct {
+	Event       string          `json:"event"`
+	ComplaintID string          `json:"complaint_id"`
+	Attempt     int             `json:"attempt"`
+	Subject     string          `json:"subject"`
+	Text        string          `json:"text"`
+	Results     []WebhookResult `json:"results"`
+	SentAt      time.Time       `json:"sent_at"`
+}
+
+// WebhookResult is the outcome of one channel in a webhook payload
+type WebhookResult struct {
+	Channel   string `json:"channel"`
+	Success   bool   `json:"success"`
+	ReceiptID string `json:"receipt_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// WebhookNotifier is a Reporter that posts signed JSON payloads to webhook URLs
+type WebhookNotifier struct {
+	urls   []string
+	secret string
+	poster httpPoster
+	now    func() time.Time
+}
+
+// NewWebhookNotifier creates a new webhook notifier that signs its requests with secret
+func NewWebhookNotifier(urls []string, secret string) (*WebhookNotifier, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("at least one URL is required")
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("secret cannot be empty")
+	}
+
+	return &WebhookNotifier{
+		urls:   urls,
+		secret: secret,
+		poster: newHTTPPoster(retryAfterHeader),
+		now:    time.Now,
+	}, nil
+}
+
+// ReportsResults marks the webhook as a Reporter so its payload includes the other channels
+func (n *WebhookNotifier) ReportsResults() {}
+
+// Send posts the message and the results of the other channels to every URL
+func (n *WebhookNotifier) Send(ctx context.Context, msg Message) (Receipt, error) {
+	now := n.now()
+	payload := WebhookPayload{
+		Event:       webhookEscalatedEvent,
+		ComplaintID: msg.ComplaintID,
+		Attempt:     msg.Attempt,
+		Subject:     msg.Subject,
+		Text:        msg.Text,
+		Results:     make([]WebhookResult, len(msg.Results)),
+		SentAt:      now.UTC(),
+	}
+	for i, result := range msg.Results {
+		payload.Results[i] = WebhookResult{
+			Channel:   result.Channel,
+			Success:   result.Err == nil,
+			ReceiptID: result.Receipt.ID,
+		}
+		if result.Err != nil {
+			payload.Results[i].Error = result.Err.Error()
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	delivery := fmt.Sprintf("%s-%d", msg.ComplaintID, msg.Attempt)
+	header := http.Header{}
+	header.Set(WebhookSignatureHeader, SignWebhook(n.secret, now, body))
+	header.Set(WebhookEventHeader, webhookEscalatedEvent)
+	header.Set(WebhookDeliveryHeader, delivery)
+
+	var errs []error
+	for _, endpoint := range n.urls {
+		status, respBody, err := n.poster.post(ctx, endpoint, body, header)
+		if err == nil && status >= 300 {
+			err = fmt.Errorf("status %d: %s", status, string(respBody))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", redactURL(endpoint), err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return Receipt{ID: delivery}, fmt.Errorf("webhook failed: %w", err)
+	}
+	return Receipt{ID: delivery, SentAt: now}, nil
+}
+
+// SignWebhook returns the signature header value for a webhook body sent at the given time.
+// The signature is the hex HMAC-SHA256 of "<unix time>.<body>" keyed with the secret.
+func SignWebhook(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, webhookMAC(secret, timestamp, body))
+}
+
+// VerifyWebhook checks a signature header against the body. Signatures older than
+// tolerance are rejected to prevent replays; a zero tolerance disables the check.
+func VerifyWebhook(secret, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	var timestamp, mac string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			mac = value
+		}
+	}
+	if timestamp == "" || mac == "" {
+		return fmt.Errorf("%w: malformed header", ErrInvalidSignature)
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", ErrInvalidSignature)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); tolerance > 0 && (age > tolerance || age < -tolerance) {
+		return fmt.Errorf("%w: timestamp outside the tolerance", ErrInvalidSignature)
+	}
+
+	if !hmac.Equal([]byte(mac), []byte(webhookMAC(secret, timestamp, body))) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// webhookMAC computes the hex HMAC-SHA256 of "<timestamp>.<body>"
+func webhookMAC(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,128 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+func TestWebhookNotifierSend(t *testing.T) {
+	now := time.Date(2025, 1, 7, 9, 30, 0, 0, time.UTC)
+
+	var payload WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Failed to read body: %v", err)
+		}
+		if err := VerifyWebhook(testWebhookSecret, r.Header.Get(WebhookSignatureHeader), body, 5*time.Minute, now); err != nil {
+			t.Errorf("Expected valid signature: %v", err)
+		}
+		if r.Header.Get(WebhookEventHeader) != "complaint.escalated" || r.Header.Get(WebhookDeliveryHeader) != "late-delivery-2" {
+			t.Errorf("Unexpected headers: %v", r.Header)
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier, err := NewWebhookNotifier([]string{server.URL}, testWebhookSecret)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	notifier.now = func() time.Time { return now }
+
+	receipt, err := notifier.Send(context.Background(), Message{
+		ComplaintID: "late-delivery",
+		Attempt:     2,
+		Subject:     "Order #1234",
+		Text:        "Where is my order?",
+		Results: Results{
+			{Channel: "email", Receipt: Receipt{ID: "op-1"}},
+			{Channel: "slack", Err: errors.New("webhook unavailable")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if receipt.ID != "late-delivery-2" {
+		t.Errorf("Expected delivery id as receipt, got %s", receipt.ID)
+	}
+
+	if payload.ComplaintID != "late-delivery" || payload.Attempt != 2 || payload.Text != "Where is my order?" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	expected := []WebhookResult{
+		{Channel: "email", Success: true, ReceiptID: "op-1"},
+		{Channel: "slack", Error: "webhook unavailable"},
+	}
+	if len(payload.Results) != 2 || payload.Results[0] != expected[0] || payload.Results[1] != expected[1] {
+		t.Errorf("Expected results %+v, got %+v", expected, payload.Results)
+	}
+}
+
+func TestWebhookNotifierErrors(t *testing.T) {
+	if _, err := NewWebhookNotifier(nil, testWebhookSecret); err == nil {
+		t.Error("Expected error without URLs")
+	}
+	if _, err := NewWebhookNotifier([]string{"https://example.com/hook"}, ""); err == nil {
+		t.Error("Expected error without secret")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	notifier, _ := NewWebhookNotifier([]string{server.URL}, testWebhookSecret)
+	if _, err := notifier.Send(context.Background(), Message{ComplaintID: "c1", Attempt: 1}); err == nil {
+		t.Error("Expected error for failing receiver")
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	at := time.Date(2025, 1, 7, 9, 30, 0, 0, time.UTC)
+	body := []byte(`{"complaint_id":"c1"}`)
+	signature := SignWebhook(testWebhookSecret, at, body)
+
+	// Computed independently with: printf '1736242200.<body>' | openssl dgst -sha256 -hmac webhook-secret
+	if expected := "t=1736242200,v1=fd6d94c6271cc9635fe9227595bb6f92b8e3c01cc083d8c47d38c5c758a7c231"; signature != expected {
+		t.Errorf("Expected signature %s, got %s", expected, signature)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		body      []byte
+		now       time.Time
+		valid     bool
+	}{
+		{"valid", testWebhookSecret, signature, body, at.Add(time.Minute), true},
+		{"wrong secret", "other-secret", signature, body, at, false},
+		{"tampered body", testWebhookSecret, signature, []byte(`{"complaint_id":"c2"}`), at, false},
+		{"expired", testWebhookSecret, signature, body, at.Add(10 * time.Minute), false},
+		{"malformed", testWebhookSecret, "v1=abc", body, at, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhook(tt.secret, tt.signature, tt.body, 5*time.Minute, tt.now)
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid signature: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Expected ErrInvalidSignature, got %v", err)
+			}
+		})
+	}
+}
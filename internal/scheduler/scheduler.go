@@ -0,0 +1,333 @@
+package scheduler
+
+import (
+	"complaint-escalator/internal/backoff"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Clock abstracts time so the scheduler can be driven by a fake clock in tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is a Clock backed by the real wall clock
+type SystemClock struct{}
+
+// Now returns the current wall clock time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends the current time on the returned channel
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// SendFunc performs a single escalation send for the given complaint
+type SendFunc func(ctx context.Context, complaintID string) error
+
+// GiveUpError marks a send failure that retrying cannot fix
+type GiveUpError struct {
+	Err error
+}
+
+func (e *GiveUpError) Error() string { return e.Err.Error() }
+func (e *GiveUpError) Unwrap() error { return e.Err }
+
+// GiveUp marks a send error that retrying cannot fix, such as a rejected recipient.
+// The scheduler skips the retries of the round and waits for the next interval.
+func GiveUp(err error) error {
+	return &GiveUpError{Err: err}
+}
+
+// TryLaterError marks a send failure that the service asked to retry after a delay
+type TryLaterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *TryLaterError) Error() string { return e.Err.Error() }
+func (e *TryLaterError) Unwrap() error { return e.Err }
+
+// TryLater marks a send error that the service asked to retry no sooner than after.
+// The scheduler waits at least that long before the retry.
+func TryLater(err error, after time.Duration) error {
+	return &TryLaterError{Err: err, After: after}
+}
+
+// NotifyFunc is called whenever the next send of a complaint has been planned
+type NotifyFunc func(complaintID string, at time.Time)
+
+// IntervalFunc returns the interval after a successful send of a complaint, or zero for the default interval
+type IntervalFunc func(complaintID string) time.Duration
+
+// WindowFunc returns the time a send planned at the given time may happen, which is the
+// same time when it is allowed and a later one when it is not
+type WindowFunc func(at time.Time) time.Time
+
+// Scheduler re-sends every scheduled complaint each interval and waits for the backoff after a failed send
+type Scheduler struct {
+	// cadence spaces successful sends and retry spaces the retries of a failed send
+	cadence backoff.Policy
+	retry   backoff.Policy
+	send    SendFunc
+	clock   Clock
+	notify  NotifyFunc
+	// intervalOf overrides the interval of single complaints
+	intervalOf IntervalFunc
+	// window moves sends out of hours to the next allowed time
+	window WindowFunc
+
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	jobs   map[string]*job
+	wg     sync.WaitGroup
+}
+
+// job is the escalation loop of a single complaint. A nil cancel means the job is not running yet.
+type job struct {
+	cancel context.CancelFunc
+	// first is when the first send is due. The zero time means immediately.
+	first time.Time
+}
+
+// NewScheduler creates a new scheduler that calls send every interval for each scheduled complaint
+func NewScheduler(interval, retryBackoff time.Duration, send SendFunc, clock Clock) (*Scheduler, error) {
+	return NewSchedulerWithPolicies(backoff.Constant(interval), backoff.Constant(retryBackoff), send, clock)
+}
+
+// NewSchedulerWithPolicies creates a new scheduler whose sends follow the cadence policy
+// and whose failed sends are retried following the retry policy. Once the retries are
+// exhausted the round is given up and the complaint waits for its next interval.
+func NewSchedulerWithPolicies(cadence, retry backoff.Policy, send SendFunc, clock Clock) (*Scheduler, error) {
+	if cadence.Initial <= 0 {
+		return nil, fmt.Errorf("interval must be positive")
+	}
+	if err := cadence.Validate(); err != nil {
+		return nil, fmt.Errorf("interval: %w", err)
+	}
+	if cadence.MaxAttempts > 0 {
+		return nil, fmt.Errorf("interval: max attempts only applies to retries")
+	}
+	if err := retry.Validate(); err != nil {
+		return nil, fmt.Errorf("backoff: %w", err)
+	}
+	if send == nil {
+		return nil, fmt.Errorf("send function is required")
+	}
+	if clock == nil {
+		clock = SystemClock{}
+	}
+
+	// Without a backoff a failed send is simply retried after the interval
+	if retry.Initial == 0 {
+		retry.Initial = cadence.Initial
+	}
+
+	return &Scheduler{
+		cadence: cadence,
+		retry:   retry,
+		send:    send,
+		clock:   clock,
+		jobs:    make(map[string]*job),
+	}, nil
+}
+
+// OnSchedule registers fn to be called with the planned time of every upcoming send,
+// so that the schedule can be persisted. It must be called before Start.
+func (s *Scheduler) OnSchedule(fn NotifyFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notify = fn
+}
+
+// OnInterval registers fn to pick the interval after each successful send of a
+// complaint, for complaints that escalate at their own pace. It must be called before Start.
+func (s *Scheduler) OnInterval(fn IntervalFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.intervalOf = fn
+}
+
+// OnWindow registers fn to move every planned send, including retries and the first
+// send, to a time the recipients accept sends at. It must be called before Start.
+func (s *Scheduler) OnWindow(fn WindowFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.window = fn
+}
+
+// Start starts the escalation loops of all scheduled complaints in the background
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	for id, j := range s.jobs {
+		s.startJob(id, j)
+	}
+}
+
+// Stop stops all escalation loops and waits for in-flight sends to finish.
+// Scheduled complaints are kept and resume on the next Start.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.ctx, s.cancel = nil, nil
+	for _, j := range s.jobs {
+		j.cancel = nil
+	}
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	s.wg.Wait()
+}
+
+// Schedule starts escalating the complaint. The first send happens at the given time,
+// or immediately when it is zero or already in the past.
+func (s *Scheduler) Schedule(complaintID string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[complaintID]; ok {
+		return
+	}
+
+	j := &job{first: at}
+	s.jobs[complaintID] = j
+	if s.ctx != nil {
+		s.startJob(complaintID, j)
+	}
+}
+
+// Unschedule stops escalating the complaint. It does not wait for an in-flight send.
+func (s *Scheduler) Unschedule(complaintID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[complaintID]
+	if !ok {
+		return
+	}
+	if j.cancel != nil {
+		j.cancel()
+	}
+	delete(s.jobs, complaintID)
+}
+
+// Scheduled reports whether the complaint is currently scheduled
+func (s *Scheduler) Scheduled(complaintID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.jobs[complaintID]
+	return ok
+}
+
+// startJob starts the escalation loop of a complaint. The caller must hold s.mu.
+func (s *Scheduler) startJob(complaintID string, j *job) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	j.cancel = cancel
+
+	first := j.first
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		s.run(ctx, complaintID, first)
+	}()
+}
+
+// run sends the complaint, then sleeps for the interval or the backoff depending on the outcome
+func (s *Scheduler) run(ctx context.Context, complaintID string, first time.Time) {
+	var delay time.Duration
+	if !first.IsZero() {
+		delay = max(first.Sub(s.clock.Now()), 0)
+	}
+
+	// sends counts the successful sends of this loop and failures the failed sends since the last success
+	var sends, failures int
+	var interval, retryDelay time.Duration
+
+	for {
+		now := s.clock.Now()
+		at := now.Add(delay)
+		if s.window != nil {
+			if allowed := s.window(at); allowed.After(at) {
+				log.Printf("Send of complaint %s at %v is outside the send window, moved to %v", complaintID, at.Format(time.RFC3339), allowed.Format(time.RFC3339))
+				at = allowed
+				delay = at.Sub(now)
+			}
+		}
+		if s.notify != nil {
+			s.notify(complaintID, at)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(delay):
+		}
+
+		if err := s.send(ctx, complaintID); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			var giveUp *GiveUpError
+			if errors.As(err, &giveUp) || s.retry.Exhausted(failures) {
+				reason := fmt.Sprintf("failed %d times", failures)
+				if giveUp != nil {
+					reason = "cannot succeed"
+				}
+				failures, retryDelay = 0, 0
+				interval = s.nextInterval(complaintID, sends+1, interval)
+				delay = interval
+				log.Printf("Escalation of complaint %s %s, giving up this round, next send in %v: %v", complaintID, reason, delay, err)
+				continue
+			}
+			retryDelay = s.retry.Delay(failures, retryDelay)
+			delay = retryDelay
+			var later *TryLaterError
+			if errors.As(err, &later) && later.After > delay {
+				delay = later.After
+			}
+			log.Printf("Escalation of complaint %s failed, retrying in %v: %v", complaintID, delay, err)
+			continue
+		}
+
+		sends++
+		failures, retryDelay = 0, 0
+		interval = s.nextInterval(complaintID, sends, interval)
+		delay = interval
+		log.Printf("Escalation of complaint %s sent, next send in %v", complaintID, delay)
+	}
+}
+
+// nextInterval returns the interval after the given send of the complaint. previous is
+// the interval before it, which decorrelated jitter grows from.
+func (s *Scheduler) nextInterval(complaintID string, send int, previous time.Duration) time.Duration {
+	if s.intervalOf != nil {
+		if interval := s.intervalOf(complaintID); interval > 0 {
+			return interval
+		}
+	}
+	return s.cadence.Delay(send, previous)
+}
@@ -0,0 +1,511 @@
+package scheduler
+
+import (
+	"complaint-escalator/internal/backoff"
+	"complaint-escalator/internal/window"
+	"complaint-escalator/pkg/testutils"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// expectSend waits for the scheduler to report a send
+func expectSend(t *testing.T, sends <-chan time.Time) time.Time {
+	t.Helper()
+	select {
+	case at := <-sends:
+		return at
+	case <-time.After(time.Second):
+		t.Fatal("expected a send but none happened")
+	}
+	return time.Time{}
+}
+
+// expectNoSend verifies that the scheduler did not send
+func expectNoSend(t *testing.T, sends <-chan time.Time) {
+	t.Helper()
+	select {
+	case at := <-sends:
+		t.Fatalf("unexpected send at %v", at)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestSchedulerSendsEveryInterval(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := testutils.NewFakeClock(start)
+
+	sends := make(chan time.Time, 10)
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- clock.Now()
+		return nil
+	}
+
+	s, err := NewScheduler(5*time.Minute, 2*time.Minute, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	s.Schedule("c1", time.Time{})
+	s.Start(context.Background())
+	defer s.Stop()
+
+	// The first send happens immediately
+	if at := expectSend(t, sends); !at.Equal(start) {
+		t.Errorf("first send at %v want %v", at, start)
+	}
+
+	// Nothing is sent before the interval elapses
+	clock.BlockUntil(1)
+	clock.Advance(4 * time.Minute)
+	expectNoSend(t, sends)
+
+	clock.Advance(time.Minute)
+	if at := expectSend(t, sends); !at.Equal(start.Add(5 * time.Minute)) {
+		t.Errorf("second send at %v want %v", at, start.Add(5*time.Minute))
+	}
+}
+
+func TestSchedulerUsesComplaintInterval(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := testutils.NewFakeClock(start)
+
+	sends := make(chan time.Time, 10)
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- clock.Now()
+		return nil
+	}
+
+	s, err := NewScheduler(5*time.Minute, 2*time.Minute, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	// The complaint escalates hourly, everything else uses the default
+	s.OnInterval(func(complaintID string) time.Duration {
+		if complaintID == "c1" {
+			return time.Hour
+		}
+		return 0
+	})
+	s.Schedule("c1", time.Time{})
+	s.Start(context.Background())
+	defer s.Stop()
+
+	expectSend(t, sends)
+
+	clock.BlockUntil(1)
+	clock.Advance(5 * time.Minute)
+	expectNoSend(t, sends)
+
+	clock.Advance(55 * time.Minute)
+	if at := expectSend(t, sends); !at.Equal(start.Add(time.Hour)) {
+		t.Errorf("second send at %v want %v", at, start.Add(time.Hour))
+	}
+}
+
+func TestSchedulerAppliesBackoffAfterFailure(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := testutils.NewFakeClock(start)
+
+	sends := make(chan time.Time, 10)
+	failures := 1
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- clock.Now()
+		if failures > 0 {
+			failures--
+			return errors.New("send failed")
+		}
+		return nil
+	}
+
+	s, err := NewScheduler(5*time.Minute, 2*time.Minute, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	s.Schedule("c1", time.Time{})
+	s.Start(context.Background())
+	defer s.Stop()
+
+	// The first send fails
+	expectSend(t, sends)
+
+	// The retry happens after the backoff rather than the interval
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Minute)
+	if at := expectSend(t, sends); !at.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("retry at %v want %v", at, start.Add(2*time.Minute))
+	}
+
+	// After a successful send the regular interval applies again
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Minute)
+	expectNoSend(t, sends)
+	clock.Advance(3 * time.Minute)
+	expectSend(t, sends)
+}
+
+func TestSchedulerGivesUpOnPermanentFailure(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := testutils.NewFakeClock(start)
+
+	sends := make(chan time.Time, 10)
+	failures := 1
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- clock.Now()
+		if failures > 0 {
+			failures--
+			return GiveUp(errors.New("invalid recipient"))
+		}
+		return nil
+	}
+
+	s, err := NewScheduler(5*time.Minute, time.Minute, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	s.Schedule("c1", time.Time{})
+	s.Start(context.Background())
+	defer s.Stop()
+
+	expectSend(t, sends)
+
+	// No retry after the backoff, the round is given up until the next interval
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	expectNoSend(t, sends)
+
+	clock.Advance(4 * time.Minute)
+	if at := expectSend(t, sends); !at.Equal(start.Add(5 * time.Minute)) {
+		t.Errorf("next send at %v want %v", at, start.Add(5*time.Minute))
+	}
+}
+
+func TestSchedulerWaitsWhenAskedToTryLater(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := testutils.NewFakeClock(start)
+
+	sends := make(chan time.Time, 10)
+	failures := 1
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- clock.Now()
+		if failures > 0 {
+			failures--
+			return TryLater(errors.New("throttled"), 3*time.Minute)
+		}
+		return nil
+	}
+
+	s, err := NewScheduler(time.Hour, time.Minute, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	s.Schedule("c1", time.Time{})
+	s.Start(context.Background())
+	defer s.Stop()
+
+	expectSend(t, sends)
+
+	// The retry waits for the requested three minutes rather than the one minute backoff
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	expectNoSend(t, sends)
+
+	clock.Advance(2 * time.Minute)
+	if at := expectSend(t, sends); !at.Equal(start.Add(3 * time.Minute)) {
+		t.Errorf("retry at %v want %v", at, start.Add(3*time.Minute))
+	}
+}
+
+func TestSchedulerRetryPolicy(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := testutils.NewFakeClock(start)
+
+	sends := make(chan time.Time, 10)
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- clock.Now()
+		return errors.New("send failed")
+	}
+
+	// Retries wait 1, 2 and 4 minutes, then the round is given up
+	retry := backoff.Policy{Strategy: backoff.StrategyExponential, Initial: time.Minute, MaxAttempts: 4}
+	s, err := NewSchedulerWithPolicies(backoff.Constant(time.Hour), retry, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	s.Schedule("c1", time.Time{})
+	s.Start(context.Background())
+	defer s.Stop()
+
+	expectSend(t, sends)
+	at := start
+	for _, delay := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		clock.BlockUntil(1)
+		clock.Advance(delay)
+		at = at.Add(delay)
+		if got := expectSend(t, sends); !got.Equal(at) {
+			t.Errorf("retry at %v want %v", got, at)
+		}
+	}
+
+	// After the fourth failure the complaint waits for its next interval
+	clock.BlockUntil(1)
+	clock.Advance(8 * time.Minute)
+	expectNoSend(t, sends)
+	clock.Advance(52 * time.Minute)
+	if got := expectSend(t, sends); !got.Equal(at.Add(time.Hour)) {
+		t.Errorf("next round at %v want %v", got, at.Add(time.Hour))
+	}
+}
+
+func TestSchedulerCadencePolicy(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := testutils.NewFakeClock(start)
+
+	sends := make(chan time.Time, 10)
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- clock.Now()
+		return nil
+	}
+
+	// Sends grow apart by a day each round, up to three days
+	cadence := backoff.Policy{Strategy: backoff.StrategyLinear, Initial: 24 * time.Hour, MaxDelay: 72 * time.Hour}
+	s, err := NewSchedulerWithPolicies(cadence, backoff.Policy{}, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	s.Schedule("c1", time.Time{})
+	s.Start(context.Background())
+	defer s.Stop()
+
+	expectSend(t, sends)
+	at := start
+	for _, days := range []int{1, 2, 3, 3} {
+		delay := time.Duration(days) * 24 * time.Hour
+		clock.BlockUntil(1)
+		clock.Advance(delay - time.Minute)
+		expectNoSend(t, sends)
+		clock.Advance(time.Minute)
+		at = at.Add(delay)
+		if got := expectSend(t, sends); !got.Equal(at) {
+			t.Errorf("send at %v want %v", got, at)
+		}
+	}
+}
+
+func TestSchedulerShiftsSendsIntoWindow(t *testing.T) {
+	// Friday 16:58, two minutes before the window closes
+	start := time.Date(2025, 1, 10, 16, 58, 0, 0, time.UTC)
+	clock := testutils.NewFakeClock(start)
+
+	sends := make(chan time.Time, 10)
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- clock.Now()
+		return nil
+	}
+
+	s, err := NewScheduler(5*time.Minute, time.Minute, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	w, err := window.New("UTC", []string{"mon", "tue", "wed", "thu", "fri"}, "09:00", "17:00")
+	if err != nil {
+		t.Fatalf("Failed to create window: %v", err)
+	}
+	s.OnWindow(w.Next)
+
+	planned := make(chan time.Time, 10)
+	s.OnSchedule(func(complaintID string, at time.Time) {
+		planned <- at
+	})
+	s.Schedule("c1", time.Time{})
+	s.Start(context.Background())
+	defer s.Stop()
+
+	// The first send is inside the window and happens immediately
+	<-planned
+	if at := expectSend(t, sends); !at.Equal(start) {
+		t.Errorf("first send at %v want %v", at, start)
+	}
+
+	// The next one would be at 17:03 and moves to Monday morning
+	monday := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+	if at := <-planned; !at.Equal(monday) {
+		t.Errorf("planned send at %v want %v", at, monday)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(5 * time.Minute)
+	expectNoSend(t, sends)
+
+	clock.Advance(monday.Sub(clock.Now()))
+	if at := expectSend(t, sends); !at.Equal(monday) {
+		t.Errorf("second send at %v want %v", at, monday)
+	}
+}
+
+func TestSchedulerShiftsResumedSendIntoWindow(t *testing.T) {
+	// Saturday
+	start := time.Date(2025, 1, 11, 12, 0, 0, 0, time.UTC)
+	clock := testutils.NewFakeClock(start)
+
+	sends := make(chan time.Time, 10)
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- clock.Now()
+		return nil
+	}
+
+	s, err := NewScheduler(5*time.Minute, time.Minute, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	w, err := window.New("UTC", []string{"mon", "tue", "wed", "thu", "fri"}, "09:00", "17:00")
+	if err != nil {
+		t.Fatalf("Failed to create window: %v", err)
+	}
+	s.OnWindow(w.Next)
+
+	// A send that was due while the service was down waits for the window too
+	s.Schedule("c1", start.Add(-time.Hour))
+	s.Start(context.Background())
+	defer s.Stop()
+
+	clock.BlockUntil(1)
+	expectNoSend(t, sends)
+
+	monday := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+	clock.Advance(monday.Sub(start))
+	if at := expectSend(t, sends); !at.Equal(monday) {
+		t.Errorf("send at %v want %v", at, monday)
+	}
+}
+
+func TestSchedulerStop(t *testing.T) {
+	clock := testutils.NewFakeClock(time.Now())
+
+	sends := make(chan time.Time, 10)
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- clock.Now()
+		return nil
+	}
+
+	s, err := NewScheduler(time.Minute, 0, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	s.Schedule("c1", time.Time{})
+	s.Start(context.Background())
+	expectSend(t, sends)
+
+	clock.BlockUntil(1)
+	s.Stop()
+
+	// No more sends after the scheduler is stopped
+	clock.Advance(time.Hour)
+	expectNoSend(t, sends)
+
+	// Stopping twice is a no-op
+	s.Stop()
+}
+
+func TestSchedulerScheduleAndUnschedule(t *testing.T) {
+	clock := testutils.NewFakeClock(time.Now())
+
+	sends := make(chan string, 10)
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- complaintID
+		return nil
+	}
+
+	s, err := NewScheduler(time.Minute, 0, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	s.Start(context.Background())
+	defer s.Stop()
+
+	// Complaints scheduled while running are sent immediately
+	s.Schedule("c1", time.Time{})
+	if id := <-sends; id != "c1" {
+		t.Errorf("sent complaint %s want c1", id)
+	}
+	s.Schedule("c2", time.Time{})
+	if id := <-sends; id != "c2" {
+		t.Errorf("sent complaint %s want c2", id)
+	}
+	if !s.Scheduled("c1") || !s.Scheduled("c2") {
+		t.Error("both complaints should be scheduled")
+	}
+
+	// Only the remaining complaint is re-sent after unscheduling
+	clock.BlockUntil(2)
+	s.Unschedule("c1")
+	if s.Scheduled("c1") {
+		t.Error("c1 should no longer be scheduled")
+	}
+	clock.Advance(time.Minute)
+	if id := <-sends; id != "c2" {
+		t.Errorf("sent complaint %s want c2", id)
+	}
+	select {
+	case id := <-sends:
+		t.Errorf("unexpected send of complaint %s", id)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestSchedulerResumesFromPlannedTime(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := testutils.NewFakeClock(start)
+
+	sends := make(chan time.Time, 10)
+	send := func(ctx context.Context, complaintID string) error {
+		sends <- clock.Now()
+		return nil
+	}
+
+	s, err := NewScheduler(5*time.Minute, 0, send, clock)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+
+	planned := make(chan time.Time, 10)
+	s.OnSchedule(func(complaintID string, at time.Time) {
+		planned <- at
+	})
+
+	// A complaint restored after a restart continues at its persisted time
+	s.Schedule("c1", start.Add(3*time.Minute))
+	s.Start(context.Background())
+	defer s.Stop()
+
+	if at := <-planned; !at.Equal(start.Add(3 * time.Minute)) {
+		t.Errorf("planned send at %v want %v", at, start.Add(3*time.Minute))
+	}
+	clock.BlockUntil(1)
+	expectNoSend(t, sends)
+
+	clock.Advance(3 * time.Minute)
+	expectSend(t, sends)
+	if at := <-planned; !at.Equal(start.Add(8 * time.Minute)) {
+		t.Errorf("planned send at %v want %v", at, start.Add(8*time.Minute))
+	}
+}
+
+func TestNewSchedulerValidation(t *testing.T) {
+	send := func(ctx context.Context, complaintID string) error { return nil }
+
+	if _, err := NewScheduler(0, time.Minute, send, nil); err == nil {
+		t.Error("Expected error for zero interval")
+	}
+	if _, err := NewScheduler(time.Minute, -time.Second, send, nil); err == nil {
+		t.Error("Expected error for negative backoff")
+	}
+	if _, err := NewSchedulerWithPolicies(backoff.Policy{Initial: time.Minute, MaxAttempts: 3}, backoff.Policy{}, send, nil); err == nil {
+		t.Error("Expected error for a cadence with max attempts")
+	}
+	if _, err := NewSchedulerWithPolicies(backoff.Constant(time.Minute), backoff.Policy{Initial: time.Second, Jitter: "some"}, send, nil); err == nil {
+		t.Error("Expected error for an invalid retry policy")
+	}
+	if _, err := NewScheduler(time.Minute, time.Second, nil, nil); err == nil {
+		t.Error("Expected error for missing send function")
+	}
+}
@@ -0,0 +1,166 @@
+package sms
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding an SMS is sent with
+type Encoding string
+
+const (
+	// GSM7 packs characters of the GSM 03.38 alphabet into 7 bits
+	GSM7 Encoding = "GSM-7"
+	// UCS2 is used as soon as a message contains any other character
+	UCS2 Encoding = "UCS-2"
+)
+
+// Segment capacities in encoding units: septets for GSM-7, UTF-16 code units for UCS-2.
+// Messages longer than one segment lose room to the concatenation header.
+const (
+	gsm7SingleSegment = 160
+	gsm7MultiSegment  = 153
+	ucs2SingleSegment = 70
+	ucs2MultiSegment  = 67
+)
+
+// truncationMarker ends truncated messages. It is in the GSM-7 alphabet so it
+// does not force UCS-2.
+const truncationMarker = "..."
+
+const (
+	// gsm7Basic is the GSM 03.38 basic character set
+	gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	// gsm7Extension holds the characters that take an escape septet plus their own
+	gsm7Extension = "\f^{}\\[~]|€"
+)
+
+// DetectEncoding returns the encoding text has to be sent with
+func DetectEncoding(text string) Encoding {
+	for _, r := range text {
+		if !strings.ContainsRune(gsm7Basic, r) && !strings.ContainsRune(gsm7Extension, r) {
+			return UCS2
+		}
+	}
+	return GSM7
+}
+
+// Segments returns the number of segments text is sent as, and its encoding
+func Segments(text string) (int, Encoding) {
+	encoding := DetectEncoding(text)
+	if text == "" {
+		return 0, encoding
+	}
+
+	single, multi := segmentCapacity(encoding)
+	total := 0
+	for _, r := range text {
+		total += runeUnits(r, encoding)
+	}
+	if total <= single {
+		return 1, encoding
+	}
+
+	// Characters are not split across segments, so an escape sequence or
+	// surrogate pair at a boundary moves to the next segment
+	segments, used := 1, 0
+	for _, r := range text {
+		units := runeUnits(r, encoding)
+		if used+units > multi {
+			segments++
+			used = 0
+		}
+		used += units
+	}
+	return segments, encoding
+}
+
+// Split splits text into messages of at most maxSegments segments each, breaking at
+// line breaks or spaces where possible
+func Split(text string, maxSegments int) []string {
+	var messages []string
+	runes := []rune(strings.TrimSpace(text))
+	for len(runes) > 0 {
+		n := longestFittingPrefix(runes, maxSegments, "")
+		if n < len(runes) {
+			if i := lastBreak(runes[:n]); i > 0 {
+				n = i + 1
+			}
+		}
+		if message := strings.TrimSpace(string(runes[:n])); message != "" {
+			messages = append(messages, message)
+		}
+		runes = runes[n:]
+	}
+	return messages
+}
+
+// Truncate shortens text to fit into maxSegments segments, marking the cut
+func Truncate(text string, maxSegments int) string {
+	if count, _ := Segments(text); count <= maxSegments {
+		return text
+	}
+
+	runes := []rune(text)
+	n := longestFittingPrefix(runes, maxSegments, truncationMarker)
+	if i := lastBreak(runes[:n]); i > 0 {
+		n = i
+	}
+	return strings.TrimRightFunc(string(runes[:n]), unicode.IsSpace) + truncationMarker
+}
+
+// longestFittingPrefix returns the length of the longest prefix of runes that, followed
+// by suffix, fits into maxSegments segments. It is at least 1 so that callers make progress.
+func longestFittingPrefix(runes []rune, maxSegments int, suffix string) int {
+	lo, hi := 1, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if count, _ := Segments(string(runes[:mid]) + suffix); count <= maxSegments {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
+
+// lastBreak returns the index of the last line break in the last quarter of runes, or
+// else of the last space. Line breaks further back would leave a short message that
+// still costs whole segments.
+func lastBreak(runes []rune) int {
+	space := -1
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] == '\n' && i >= len(runes)*3/4 {
+			return i
+		}
+		if space < 0 && unicode.IsSpace(runes[i]) {
+			space = i
+		}
+	}
+	return space
+}
+
+// segmentCapacity returns the single and multi-part segment capacity of an encoding
+func segmentCapacity(encoding Encoding) (single, multi int) {
+	if encoding == GSM7 {
+		return gsm7SingleSegment, gsm7MultiSegment
+	}
+	return ucs2SingleSegment, ucs2MultiSegment
+}
+
+// runeUnits returns how many encoding units a character takes
+func runeUnits(r rune, encoding Encoding) int {
+	if encoding == GSM7 {
+		if strings.ContainsRune(gsm7Extension, r) {
+			return 2
+		}
+		return 1
+	}
+	if utf8.RuneLen(r) == 4 {
+		// Characters outside the Basic Multilingual Plane are a UTF-16 surrogate pair
+		return 2
+	}
+	return 1
+}
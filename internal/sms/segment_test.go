@@ -0,0 +1,96 @@
+package sms
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		segments int
+		encoding Encoding
+	}{
+		{"empty", "", 0, GSM7},
+		{"short GSM-7", "Where is my order?", 1, GSM7},
+		{"full GSM-7 segment", strings.Repeat("a", 160), 1, GSM7},
+		{"two GSM-7 segments", strings.Repeat("a", 161), 2, GSM7},
+		{"three GSM-7 segments", strings.Repeat("a", 307), 3, GSM7},
+		{"extension characters count twice", strings.Repeat("€", 80), 1, GSM7},
+		{"extension character over the limit", strings.Repeat("€", 81), 2, GSM7},
+		{"full UCS-2 segment", strings.Repeat("ş", 70), 1, UCS2},
+		{"two UCS-2 segments", strings.Repeat("ş", 71), 2, UCS2},
+		{"surrogate pairs count twice", strings.Repeat("😀", 35), 1, UCS2},
+		{"one character forces UCS-2", strings.Repeat("a", 100) + "😀", 2, UCS2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments, encoding := Segments(tt.text)
+			if segments != tt.segments || encoding != tt.encoding {
+				t.Errorf("Segments() = %d %s, want %d %s", segments, encoding, tt.segments, tt.encoding)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	text := strings.Repeat("Please refund order 1234. ", 40)
+
+	messages := Split(text, 2)
+	if len(messages) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(messages))
+	}
+	for i, message := range messages {
+		if segments, _ := Segments(message); segments > 2 {
+			t.Errorf("Message %d has %d segments", i, segments)
+		}
+	}
+	// Messages break between words
+	if joined := strings.Join(messages, " "); joined != strings.TrimSpace(text) {
+		t.Error("Split messages should contain the whole text")
+	}
+
+	if messages := Split("short", 1); len(messages) != 1 || messages[0] != "short" {
+		t.Errorf("Expected short text unchanged, got %q", messages)
+	}
+}
+
+func TestSplitBreaks(t *testing.T) {
+	words := strings.Repeat("word ", 40)
+
+	tests := []struct {
+		name  string
+		text  string
+		first string
+	}{
+		// A line break near the start would leave a tiny first message
+		{"early line break", "Hi\n" + words, "Hi\n" + strings.TrimSpace(words[:155])},
+		{"late line break", words[:140] + "\n" + words, strings.TrimSpace(words[:140])},
+		{"hard cut", strings.Repeat("x", 200), strings.Repeat("x", 160)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if messages := Split(tt.text, 1); messages[0] != tt.first {
+				t.Errorf("First message %q, want %q", messages[0], tt.first)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := Truncate("short", 1); got != "short" {
+		t.Errorf("Expected short text unchanged, got %q", got)
+	}
+
+	text := strings.Repeat("Please refund order 1234. ", 40)
+	truncated := Truncate(text, 1)
+	if segments, _ := Segments(truncated); segments != 1 {
+		t.Errorf("Expected 1 segment, got %d", segments)
+	}
+	if !strings.HasSuffix(truncated, "...") || !strings.HasPrefix(text, strings.TrimSuffix(truncated, "...")) {
+		t.Errorf("Unexpected truncation: %q", truncated)
+	}
+}
@@ -0,0 +1,162 @@
+package sms
+
+import (
+	"bytes"
+	"complaint-escalator/internal/email"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// apiVersion is the version of the Azure Communication Services SMS API
+const apiVersion = "2021-03-07"
+
+// e164Pattern matches phone numbers in E.164 format, such as +6591234567
+var e164Pattern = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
+// ValidateNumber checks that a phone number is in E.164 format
+func ValidateNumber(number string) error {
+	if !e164Pattern.MatchString(number) {
+		return fmt.Errorf("phone number %q is not in E.164 format", number)
+	}
+	return nil
+}
+
+// SMSClient represents an Azure Communication Services SMS client
+type SMSClient struct {
+	httpClient *http.Client
+	endpoint   string
+	signer     *email.Signer
+}
+
+// SMSMessage represents an SMS to be sent to several recipients
+type SMSMessage struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Message string   `json:"message"`
+}
+
+// SendResult is the outcome of sending an SMS to one recipient
+type SendResult struct {
+	To             string `json:"to"`
+	MessageID      string `json:"messageId,omitempty"`
+	HTTPStatusCode int    `json:"httpStatusCode"`
+	Successful     bool   `json:"successful"`
+	ErrorMessage   string `json:"errorMessage,omitempty"`
+}
+
+// Azure SMS API request structure
+type azureSMSRequest struct {
+	From          string              `json:"from"`
+	SMSRecipients []azureSMSRecipient `json:"smsRecipients"`
+	Message       string              `json:"message"`
+	SendOptions   struct {
+		EnableDeliveryReport bool `json:"enableDeliveryReport"`
+	} `json:"smsSendOptions"`
+}
+
+type azureSMSRecipient struct {
+	To string `json:"to"`
+}
+
+type azureSMSResponse struct {
+	Value []SendResult `json:"value"`
+}
+
+// NewSMSClient creates a new SMS client using the same connection string as the email client
+func NewSMSClient(connectionString string) (*SMSClient, error) {
+	if connectionString == "" {
+		return nil, fmt.Errorf("connection string cannot be empty")
+	}
+
+	endpoint, accessKey, err := email.ParseConnectionString(connectionString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+
+	return &SMSClient{
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		signer:   email.NewSigner(accessKey),
+	}, nil
+}
+
+// SendSMS sends the message to every recipient and returns one result per recipient.
+// An error is only returned when the request as a whole fails; recipients that were
+// rejected are reported in their result.
+func (c *SMSClient) SendSMS(ctx context.Context, msg SMSMessage) ([]SendResult, error) {
+	if err := validateMessage(msg); err != nil {
+		return nil, fmt.Errorf("invalid SMS message: %w", err)
+	}
+
+	smsReq := azureSMSRequest{
+		From:    msg.From,
+		Message: msg.Message,
+	}
+	for _, to := range msg.To {
+		smsReq.SMSRecipients = append(smsReq.SMSRecipients, azureSMSRecipient{To: to})
+	}
+	smsReq.SendOptions.EnableDeliveryReport = true
+
+	jsonData, err := json.Marshal(smsReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SMS request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/sms?api-version=%s", c.endpoint, apiVersion)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := c.signer.Sign(req, jsonData); err != nil {
+		return nil, fmt.Errorf("failed to sign HTTP request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("SMS send failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var smsResp azureSMSResponse
+	if err := json.Unmarshal(body, &smsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
+	return smsResp.Value, nil
+}
+
+// validateMessage validates the SMS message
+func validateMessage(msg SMSMessage) error {
+	if err := ValidateNumber(msg.From); err != nil {
+		return fmt.Errorf("sender: %w", err)
+	}
+	if len(msg.To) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+	for _, to := range msg.To {
+		if err := ValidateNumber(to); err != nil {
+			return fmt.Errorf("recipient: %w", err)
+		}
+	}
+	if msg.Message == "" {
+		return fmt.Errorf("message is required")
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testAccessKey is base64("complaint-escalator-test-key-32b")
+const testAccessKey = "Y29tcGxhaW50LWVzY2FsYXRvci10ZXN0LWtleS0zMmI="
+
+func TestValidateNumber(t *testing.T) {
+	valid := []string{"+6591234567", "+14255550123", "+442071838750"}
+	for _, number := range valid {
+		if err := ValidateNumber(number); err != nil {
+			t.Errorf("Expected %s to be valid: %v", number, err)
+		}
+	}
+
+	invalid := []string{"", "6591234567", "+0591234567", "+65 9123 4567", "+1234567890123456", "+65-9123-4567"}
+	for _, number := range invalid {
+		if err := ValidateNumber(number); err == nil {
+			t.Errorf("Expected %s to be invalid", number)
+		}
+	}
+}
+
+func TestSendSMS(t *testing.T) {
+	var received azureSMSRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sms" || r.URL.Query().Get("api-version") != apiVersion {
+			t.Errorf("Unexpected URL %s", r.URL)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "HMAC-SHA256 ") {
+			t.Errorf("Expected signed request, got %s", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `{"value":[
+			{"to":"+6591234567","messageId":"msg-1","httpStatusCode":202,"successful":true},
+			{"to":"+6598765432","httpStatusCode":400,"successful":false,"errorMessage":"Invalid To phone number format."}
+		]}`)
+	}))
+	defer server.Close()
+
+	client, err := NewSMSClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create SMS client: %v", err)
+	}
+
+	results, err := client.SendSMS(context.Background(), SMSMessage{
+		From:    "+18335550100",
+		To:      []string{"+6591234567", "+6598765432"},
+		Message: "Where is my order?",
+	})
+	if err != nil {
+		t.Fatalf("Failed to send SMS: %v", err)
+	}
+
+	if received.From != "+18335550100" || len(received.SMSRecipients) != 2 || received.Message != "Where is my order?" {
+		t.Errorf("Unexpected request: %+v", received)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if !results[0].Successful || results[0].MessageID != "msg-1" {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+	if results[1].Successful || results[1].ErrorMessage == "" {
+		t.Errorf("Unexpected second result: %+v", results[1])
+	}
+}
+
+func TestSendSMSErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"code":"Denied","message":"Denied by the resource provider."}}`)
+	}))
+	defer server.Close()
+
+	client, err := NewSMSClient(fmt.Sprintf("endpoint=%s/;accesskey=%s", server.URL, testAccessKey))
+	if err != nil {
+		t.Fatalf("Failed to create SMS client: %v", err)
+	}
+
+	// Invalid numbers are rejected before sending
+	if _, err := client.SendSMS(context.Background(), SMSMessage{From: "+18335550100", To: []string{"12345"}, Message: "Text"}); err == nil {
+		t.Error("Expected error for invalid recipient")
+	}
+
+	if _, err := client.SendSMS(context.Background(), SMSMessage{From: "+18335550100", To: []string{"+6591234567"}, Message: "Text"}); err == nil {
+		t.Error("Expected error for rejected request")
+	}
+
+	if _, err := NewSMSClient(""); err == nil {
+		t.Error("Expected error for empty connection string")
+	}
+}
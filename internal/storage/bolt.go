@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"bytes"
+	"complaint-escalator/internal/complaint"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	complaintsBucket = []byte("complaints")
+	attemptsBucket   = []byte("attempts")
+)
+
+// BoltStore is a Store backed by an embedded BoltDB file
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens or creates the BoltDB file at path
+func NewBoltStore(path string) (*BoltStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("storage path cannot be empty")
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{complaintsBucket, attemptsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create buckets: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// AddComplaint adds a new complaint to the store
+func (s *BoltStore) AddComplaint(c complaint.Complaint) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(complaintsBucket)
+		if b.Get([]byte(c.ID)) != nil {
+			return fmt.Errorf("%w: %s", complaint.ErrAlreadyExists, c.ID)
+		}
+		return putJSON(b, []byte(c.ID), c)
+	})
+}
+
+// GetComplaint returns the complaint with the given id
+func (s *BoltStore) GetComplaint(id string) (complaint.Complaint, error) {
+	var c complaint.Complaint
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getComplaint(tx, id, &c)
+	})
+	return c, err
+}
+
+// ListComplaints returns all complaints ordered by creation time
+func (s *BoltStore) ListComplaints() ([]complaint.Complaint, error) {
+	result := []complaint.Complaint{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(complaintsBucket).ForEach(func(k, v []byte) error {
+			var c complaint.Complaint
+			if err := json.Unmarshal(v, &c); err != nil {
+				return fmt.Errorf("failed to decode complaint %s: %w", k, err)
+			}
+			result = append(result, c)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortComplaints(result)
+	return result, nil
+}
+
+// UpdateComplaint applies fn to the stored complaint and saves the result if fn succeeds
+func (s *BoltStore) UpdateComplaint(id string, fn func(c *complaint.Complaint) error) (complaint.Complaint, error) {
+	var c complaint.Complaint
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := getComplaint(tx, id, &c); err != nil {
+			return err
+		}
+		if err := fn(&c); err != nil {
+			return err
+		}
+		return putJSON(tx.Bucket(complaintsBucket), []byte(id), c)
+	})
+	if err != nil {
+		return complaint.Complaint{}, err
+	}
+	return c, nil
+}
+
+// RecordAttempt appends an attempt to the send history
+func (s *BoltStore) RecordAttempt(a Attempt) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(attemptsBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return putJSON(b, attemptKey(a.ComplaintID, seq), a)
+	})
+}
+
+// ListAttempts returns the send history of a complaint in the order it was recorded
+func (s *BoltStore) ListAttempts(complaintID string) ([]Attempt, error) {
+	result := []Attempt{}
+	prefix := attemptKey(complaintID, 0)[:len(complaintID)+1]
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(attemptsBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var a Attempt
+			if err := json.Unmarshal(v, &a); err != nil {
+				return fmt.Errorf("failed to decode attempt %s: %w", k, err)
+			}
+			result = append(result, a)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Close closes the database file
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// getComplaint decodes the complaint with the given id into c
+func getComplaint(tx *bolt.Tx, id string, c *complaint.Complaint) error {
+	v := tx.Bucket(complaintsBucket).Get([]byte(id))
+	if v == nil {
+		return fmt.Errorf("%w: %s", complaint.ErrNotFound, id)
+	}
+	if err := json.Unmarshal(v, c); err != nil {
+		return fmt.Errorf("failed to decode complaint %s: %w", id, err)
+	}
+	return nil
+}
+
+// putJSON stores v as JSON under key
+func putJSON(b *bolt.Bucket, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	return b.Put(key, data)
+}
+
+// attemptKey builds a key that sorts attempts by complaint and then by sequence.
+// Complaint ids never contain a NUL byte, so the separator keeps prefixes unambiguous.
+func attemptKey(complaintID string, seq uint64) []byte {
+	return []byte(fmt.Sprintf("%s\x00%020d", complaintID, seq))
+}
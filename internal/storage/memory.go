@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"complaint-escalator/internal/complaint"
+	"fmt"
+	"sync"
+)
+
+// MemoryStore is an in-memory Store. Its contents are lost when the process exits.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	complaints map[string]complaint.Complaint
+	attempts   map[string][]Attempt
+}
+
+// NewMemoryStore creates a new empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		complaints: make(map[string]complaint.Complaint),
+		attempts:   make(map[string][]Attempt),
+	}
+}
+
+// AddComplaint adds a new complaint to the store
+func (s *MemoryStore) AddComplaint(c complaint.Complaint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.complaints[c.ID]; ok {
+		return fmt.Errorf("%w: %s", complaint.ErrAlreadyExists, c.ID)
+	}
+	s.complaints[c.ID] = c
+	return nil
+}
+
+// GetComplaint returns the complaint with the given id
+func (s *MemoryStore) GetComplaint(id string) (complaint.Complaint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.complaints[id]
+	if !ok {
+		return complaint.Complaint{}, fmt.Errorf("%w: %s", complaint.ErrNotFound, id)
+	}
+	return c, nil
+}
+
+// ListComplaints returns all complaints ordered by creation time
+func (s *MemoryStore) ListComplaints() ([]complaint.Complaint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]complaint.Complaint, 0, len(s.complaints))
+	for _, c := range s.complaints {
+		result = append(result, c)
+	}
+	sortComplaints(result)
+	return result, nil
+}
+
+// UpdateComplaint applies fn to the stored complaint and saves the result if fn succeeds
+func (s *MemoryStore) UpdateComplaint(id string, fn func(c *complaint.Complaint) error) (complaint.Complaint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.complaints[id]
+	if !ok {
+		return complaint.Complaint{}, fmt.Errorf("%w: %s", complaint.ErrNotFound, id)
+	}
+	if err := fn(&c); err != nil {
+		return complaint.Complaint{}, err
+	}
+	s.complaints[id] = c
+	return c, nil
+}
+
+// RecordAttempt appends an attempt to the send history
+func (s *MemoryStore) RecordAttempt(a Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts[a.ComplaintID] = append(s.attempts[a.ComplaintID], a)
+	return nil
+}
+
+// ListAttempts returns the send history of a complaint
+func (s *MemoryStore) ListAttempts(complaintID string) ([]Attempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]Attempt(nil), s.attempts[complaintID]...), nil
+}
+
+// Close is a no-op for the in-memory store
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"complaint-escalator/internal/complaint"
+	"sort"
+	"time"
+)
+
+// Attempt records a single send of a complaint through one channel
+type Attempt struct {
+	// ComplaintID is empty for one-off sends that do not belong to a complaint
+	ComplaintID string `json:"complaint_id,omitempty"`
+	// Number is the escalation round, or zero for sends made through /email/send
+	Number  int       `json:"number"`
+	Channel string    `json:"channel"`
+	SentAt  time.Time `json:"sent_at"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+	// OperationID is the id of the provider operation, such as an ACS email send
+	OperationID string `json:"operation_id,omitempty"`
+}
+
+// Store persists complaints and the history of their sends
+type Store interface {
+	// AddComplaint adds a new complaint. It fails with complaint.ErrAlreadyExists for a duplicate id.
+	AddComplaint(c complaint.Complaint) error
+	// GetComplaint returns a complaint. It fails with complaint.ErrNotFound for an unknown id.
+	GetComplaint(id string) (complaint.Complaint, error)
+	// ListComplaints returns all complaints ordered by creation time
+	ListComplaints() ([]complaint.Complaint, error)
+	// UpdateComplaint applies fn to a complaint and saves the result if fn succeeds
+	UpdateComplaint(id string, fn func(c *complaint.Complaint) error) (complaint.Complaint, error)
+	// RecordAttempt appends an attempt to the send history
+	RecordAttempt(a Attempt) error
+	// ListAttempts returns the send history of a complaint in the order it was recorded
+	ListAttempts(complaintID string) ([]Attempt, error)
+	// Close releases the resources held by the store
+	Close() error
+}
+
+// sortComplaints orders complaints by creation time, then by id
+func sortComplaints(complaints []complaint.Complaint) {
+	sort.Slice(complaints, func(i, j int) bool {
+		if complaints[i].CreatedAt.Equal(complaints[j].CreatedAt) {
+			return complaints[i].ID < complaints[j].ID
+		}
+		return complaints[i].CreatedAt.Before(complaints[j].CreatedAt)
+	})
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"complaint-escalator/internal/complaint"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// storeFactories creates each Store implementation for the shared tests
+var storeFactories = map[string]func(t *testing.T) Store{
+	"memory": func(t *testing.T) Store {
+		return NewMemoryStore()
+	},
+	"bolt": func(t *testing.T) Store {
+		store, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatalf("Failed to open bolt store: %v", err)
+		}
+		return store
+	},
+}
+
+func newTestComplaint(t *testing.T, id string, now time.Time) complaint.Complaint {
+	t.Helper()
+	c, err := complaint.New(id, "Late delivery", "My order #1234 has not arrived.", complaint.Recipients{To: []string{"support@example.com"}}, []string{"email"}, now)
+	if err != nil {
+		t.Fatalf("Failed to create complaint: %v", err)
+	}
+	return c
+}
+
+func TestStoreComplaints(t *testing.T) {
+	for name, newStore := range storeFactories {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			defer store.Close()
+			now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+			if err := store.AddComplaint(newTestComplaint(t, "b", now.Add(time.Minute))); err != nil {
+				t.Fatalf("Failed to add complaint: %v", err)
+			}
+			if err := store.AddComplaint(newTestComplaint(t, "a", now)); err != nil {
+				t.Fatalf("Failed to add complaint: %v", err)
+			}
+			if err := store.AddComplaint(newTestComplaint(t, "a", now)); !errors.Is(err, complaint.ErrAlreadyExists) {
+				t.Errorf("Expected ErrAlreadyExists, got %v", err)
+			}
+
+			// List is ordered by creation time
+			list, err := store.ListComplaints()
+			if err != nil {
+				t.Fatalf("Failed to list complaints: %v", err)
+			}
+			if len(list) != 2 || list[0].ID != "a" || list[1].ID != "b" {
+				t.Errorf("Unexpected list order: %v", list)
+			}
+
+			if _, err := store.GetComplaint("missing"); !errors.Is(err, complaint.ErrNotFound) {
+				t.Errorf("Expected ErrNotFound, got %v", err)
+			}
+			if _, err := store.UpdateComplaint("missing", func(c *complaint.Complaint) error { return nil }); !errors.Is(err, complaint.ErrNotFound) {
+				t.Errorf("Expected ErrNotFound, got %v", err)
+			}
+
+			// A failed update leaves the complaint untouched
+			if _, err := store.UpdateComplaint("a", func(c *complaint.Complaint) error {
+				return c.Transition(complaint.StateResolved, now)
+			}); err != nil {
+				t.Fatalf("Failed to update complaint: %v", err)
+			}
+			if _, err := store.UpdateComplaint("a", func(c *complaint.Complaint) error {
+				c.Subject = "changed"
+				return c.Transition(complaint.StateEscalating, now)
+			}); !errors.Is(err, complaint.ErrInvalidTransition) {
+				t.Errorf("Expected ErrInvalidTransition, got %v", err)
+			}
+
+			c, err := store.GetComplaint("a")
+			if err != nil {
+				t.Fatalf("Failed to get complaint: %v", err)
+			}
+			if c.State != complaint.StateResolved || c.Subject != "Late delivery" {
+				t.Errorf("Unexpected stored complaint: %+v", c)
+			}
+		})
+	}
+}
+
+func TestStoreAttempts(t *testing.T) {
+	for name, newStore := range storeFactories {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			defer store.Close()
+			now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+			attempts := []Attempt{
+				{ComplaintID: "a", Number: 1, Channel: "email", SentAt: now, Success: false, Error: "timeout"},
+				{ComplaintID: "ab", Number: 1, Channel: "email", SentAt: now, Success: true},
+				{ComplaintID: "a", Number: 1, Channel: "email", SentAt: now.Add(time.Minute), Success: true},
+			}
+			for _, a := range attempts {
+				if err := store.RecordAttempt(a); err != nil {
+					t.Fatalf("Failed to record attempt: %v", err)
+				}
+			}
+
+			// Only the attempts of the complaint are returned, in order
+			history, err := store.ListAttempts("a")
+			if err != nil {
+				t.Fatalf("Failed to list attempts: %v", err)
+			}
+			if len(history) != 2 {
+				t.Fatalf("Expected 2 attempts, got %d", len(history))
+			}
+			if history[0].Error != "timeout" || !history[1].Success {
+				t.Errorf("Unexpected attempt order: %+v", history)
+			}
+
+			history, err = store.ListAttempts("missing")
+			if err != nil {
+				t.Fatalf("Failed to list attempts: %v", err)
+			}
+			if len(history) != 0 {
+				t.Errorf("Expected no attempts, got %d", len(history))
+			}
+		})
+	}
+}
+
+func TestBoltStorePersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	next := now.Add(5 * time.Minute)
+
+	store, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open bolt store: %v", err)
+	}
+	c := newTestComplaint(t, "a", now)
+	c.NextSendAt = &next
+	if err := store.AddComplaint(c); err != nil {
+		t.Fatalf("Failed to add complaint: %v", err)
+	}
+	if err := store.RecordAttempt(Attempt{ComplaintID: "a", Number: 1, Channel: "email", SentAt: now, Success: true}); err != nil {
+		t.Fatalf("Failed to record attempt: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close bolt store: %v", err)
+	}
+
+	// Reopen the same file
+	store, err = NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen bolt store: %v", err)
+	}
+	defer store.Close()
+
+	c, err = store.GetComplaint("a")
+	if err != nil {
+		t.Fatalf("Complaint not persisted: %v", err)
+	}
+	if c.NextSendAt == nil || !c.NextSendAt.Equal(next) {
+		t.Errorf("Expected next send at %v, got %v", next, c.NextSendAt)
+	}
+	history, err := store.ListAttempts("a")
+	if err != nil || len(history) != 1 {
+		t.Errorf("Attempts not persisted: %v, %v", history, err)
+	}
+}
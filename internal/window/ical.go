@@ -0,0 +1,263 @@
+package window
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// date is a day of the calendar, independent of any time zone
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+// dateOf returns the day of t in its location
+func dateOf(t time.Time) date {
+	return date{t.Year(), t.Month(), t.Day()}
+}
+
+// yearlyDay is a day that recurs every year
+type yearlyDay struct {
+	month time.Month
+	day   int
+}
+
+// Calendar is a set of holidays. Each holiday covers whole days.
+type Calendar struct {
+	dates map[date]bool
+	// yearly holds the days of yearly holidays with the first year they apply to
+	yearly map[yearlyDay]int
+}
+
+// LoadCalendar loads holidays from an iCalendar (.ics) file
+func LoadCalendar(path string) (*Calendar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open holiday calendar: %w", err)
+	}
+	defer file.Close()
+
+	calendar, err := ParseCalendar(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse holiday calendar %s: %w", path, err)
+	}
+	return calendar, nil
+}
+
+// ParseCalendar reads the events of an iCalendar as holidays. Every day an event touches
+// is a holiday. Yearly recurring events (RRULE:FREQ=YEARLY) repeat on the same days every
+// year; events with other recurrence rules are logged and only cover their first occurrence.
+func ParseCalendar(r io.Reader) (*Calendar, error) {
+	calendar := &Calendar{dates: make(map[date]bool), yearly: make(map[yearlyDay]int)}
+
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var event *icalEvent
+	for i, line := range lines {
+		name, params, value, ok := parseLine(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid content line %q", i+1, line)
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			event = &icalEvent{}
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if event == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN:VEVENT", i+1)
+			}
+			if err := calendar.add(*event); err != nil {
+				return nil, fmt.Errorf("event %q: %w", event.summary, err)
+			}
+			event = nil
+		case event == nil:
+			// Properties outside events describe the calendar itself
+		case name == "SUMMARY":
+			event.summary = value
+		case name == "DTSTART":
+			if event.start, event.allDay, err = parseICalTime(params, value); err != nil {
+				return nil, fmt.Errorf("line %d: DTSTART: %w", i+1, err)
+			}
+		case name == "DTEND":
+			if event.end, _, err = parseICalTime(params, value); err != nil {
+				return nil, fmt.Errorf("line %d: DTEND: %w", i+1, err)
+			}
+		case name == "RRULE":
+			event.rule = value
+		}
+	}
+	if event != nil {
+		return nil, fmt.Errorf("unterminated VEVENT %q", event.summary)
+	}
+	return calendar, nil
+}
+
+// Contains reports whether the day of t, in the location of t, is a holiday
+func (c *Calendar) Contains(t time.Time) bool {
+	d := dateOf(t)
+	if c.dates[d] {
+		return true
+	}
+	first, ok := c.yearly[yearlyDay{d.month, d.day}]
+	return ok && d.year >= first
+}
+
+// Len returns the number of holiday days and yearly holiday days in the calendar
+func (c *Calendar) Len() int {
+	return len(c.dates) + len(c.yearly)
+}
+
+// icalEvent holds the properties of a VEVENT that matter for holidays
+type icalEvent struct {
+	summary    string
+	start, end time.Time
+	allDay     bool
+	rule       string
+}
+
+// add adds the days of an event to the calendar
+func (c *Calendar) add(event icalEvent) error {
+	if event.start.IsZero() {
+		return fmt.Errorf("DTSTART is required")
+	}
+
+	// Other recurrence rules are ignored, so the event only covers its first occurrence
+	yearly := false
+	if event.rule != "" {
+		yearly = yearlyRule(event.rule)
+		if !yearly {
+			log.Printf("Holiday %q: ignoring unsupported recurrence rule %q, only FREQ=YEARLY is supported", event.summary, event.rule)
+		}
+	}
+
+	// An event without an end lasts one day. The end of an all-day event is exclusive,
+	// and a timed event ending at midnight does not touch the following day.
+	first := dayTime(dateOf(event.start))
+	last := first
+	if !event.end.IsZero() {
+		if event.end.Before(event.start) {
+			return fmt.Errorf("DTEND is before DTSTART")
+		}
+		end := event.end
+		if event.allDay || (end.Equal(midnight(end)) && end.After(event.start)) {
+			end = end.Add(-time.Nanosecond)
+		}
+		if day := dayTime(dateOf(end)); day.After(first) {
+			last = day
+		}
+	}
+
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		d := dateOf(day)
+		if !yearly {
+			c.dates[d] = true
+			continue
+		}
+		key := yearlyDay{d.month, d.day}
+		if since, ok := c.yearly[key]; !ok || d.year < since {
+			c.yearly[key] = d.year
+		}
+	}
+	return nil
+}
+
+// dayTime returns midnight UTC of the day
+func dayTime(d date) time.Time {
+	return time.Date(d.year, d.month, d.day, 0, 0, 0, 0, time.UTC)
+}
+
+// unfold reads the content lines of an iCalendar, joining folded lines that continue
+// with a leading space or tab
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// parseLine splits a content line such as "DTSTART;VALUE=DATE:20250101" into its
+// upper-cased name, its parameters and its value
+func parseLine(line string) (string, map[string]string, string, bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, "", false
+	}
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+// parseICalTime parses a DATE or DATE-TIME value and reports whether it is a date.
+// Floating times and times with a TZID are read in that zone, or UTC if it is unknown.
+func parseICalTime(params map[string]string, value string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, false, nil
+	}
+
+	location := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			location = loc
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, location)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t, false, nil
+}
+
+// yearlyRule reports whether a recurrence rule such as "FREQ=YEARLY;INTERVAL=1" repeats
+// an event every year without further restrictions
+func yearlyRule(rule string) bool {
+	yearly := false
+	for _, part := range strings.Split(rule, ";") {
+		key, value, _ := strings.Cut(strings.ToUpper(part), "=")
+		switch {
+		case key == "FREQ" && value == "YEARLY":
+			yearly = true
+		case key == "INTERVAL" && value == "1":
+		default:
+			return false
+		}
+	}
+	return yearly
+}
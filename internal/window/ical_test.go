@@ -0,0 +1,136 @@
+package window
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCalendar has a yearly holiday, a one-off all-day event, a folded summary and a
+// timed event
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Example//Holidays//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:new-year@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20250101\r\n" +
+	"DTEND;VALUE=DATE:20250102\r\n" +
+	"RRULE:FREQ=YEARLY\r\n" +
+	"SUMMARY:New Year's Day\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:bridge@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20260102\r\n" +
+	"SUMMARY:Bridge day between New Year's Day and the week\r\n" +
+	" end\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:offsite@example.com\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20250618T090000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20250619T000000\r\n" +
+	"SUMMARY:Team offsite\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holidays@example.com\r\n" +
+	"DTSTART:20250804\r\n" +
+	"DTEND:20250809\r\n" +
+	"SUMMARY:Summer closure\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseCalendar(t *testing.T) {
+	calendar, err := ParseCalendar(strings.NewReader(testCalendar))
+	if err != nil {
+		t.Fatalf("Failed to parse calendar: %v", err)
+	}
+
+	tests := []struct {
+		day      time.Time
+		expected bool
+	}{
+		{time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), true},
+		{time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC), false},
+		// Yearly holidays repeat, but not before they start
+		{time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2027, 1, 2, 0, 0, 0, 0, time.UTC), false},
+		// A timed event ending at midnight only covers its own day
+		{time.Date(2025, 6, 18, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC), false},
+		// The end of an all-day event is exclusive
+		{time.Date(2025, 8, 4, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2025, 8, 8, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2025, 8, 9, 0, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		if got := calendar.Contains(tt.day); got != tt.expected {
+			t.Errorf("Contains(%s): got %v want %v", tt.day.Format(time.DateOnly), got, tt.expected)
+		}
+	}
+}
+
+func TestParseCalendarErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		calendar string
+	}{
+		{"missing start", "BEGIN:VEVENT\nSUMMARY:Nothing\nEND:VEVENT\n"},
+		{"invalid date", "BEGIN:VEVENT\nDTSTART;VALUE=DATE:2025-01-01\nEND:VEVENT\n"},
+		{"end before start", "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20250105\nDTEND;VALUE=DATE:20250101\nEND:VEVENT\n"},
+		{"unterminated event", "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20250101\n"},
+		{"invalid line", "BEGIN:VEVENT\nnot a property\nEND:VEVENT\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCalendar(strings.NewReader(tt.calendar)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestParseCalendarIgnoresUnsupportedRules(t *testing.T) {
+	calendar, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\n" +
+		"BEGIN:VEVENT\nSUMMARY:Team day\nDTSTART;VALUE=DATE:20250106\nRRULE:FREQ=WEEKLY;BYDAY=MO\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nSUMMARY:New Year\nDTSTART;VALUE=DATE:20250101\nRRULE:FREQ=YEARLY\nEND:VEVENT\n" +
+		"END:VCALENDAR\n"))
+	if err != nil {
+		t.Fatalf("Expected the calendar to load, got %v", err)
+	}
+
+	// The event with the unsupported rule covers its first day only
+	if !calendar.Contains(time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)) {
+		t.Error("Expected the first occurrence to be a holiday")
+	}
+	if calendar.Contains(time.Date(2025, 1, 13, 12, 0, 0, 0, time.UTC)) {
+		t.Error("Expected the unsupported recurrence to be ignored")
+	}
+	// The other holidays of the file still apply
+	if !calendar.Contains(time.Date(2027, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Error("Expected the yearly holiday to apply")
+	}
+}
+
+func TestLoadCalendar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "holidays.ics")
+	if err := os.WriteFile(path, []byte(testCalendar), 0600); err != nil {
+		t.Fatalf("Failed to write calendar: %v", err)
+	}
+
+	calendar, err := LoadCalendar(path)
+	if err != nil {
+		t.Fatalf("Failed to load calendar: %v", err)
+	}
+	if calendar.Len() == 0 {
+		t.Error("Expected holidays in the loaded calendar")
+	}
+
+	if _, err := LoadCalendar(filepath.Join(t.TempDir(), "missing.ics")); err == nil {
+		t.Error("Expected error for a missing calendar")
+	}
+}
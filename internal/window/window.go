@@ -0,0 +1,146 @@
+package window
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Time zones must load in minimal containers without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// searchDays limits how far ahead Next looks for an allowed slot
+const searchDays = 2 * 366
+
+// dayNames maps weekday names and their three letter abbreviations to weekdays
+var dayNames = map[string]time.Weekday{}
+
+func init() {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		dayNames[name] = d
+		dayNames[name[:3]] = d
+	}
+}
+
+// Window describes when sends are allowed: on certain weekdays between a start and an
+// end time of day in a time zone, except on holidays
+type Window struct {
+	location *time.Location
+	days     [7]bool
+	// start and end are offsets from midnight. The end is exclusive.
+	start, end time.Duration
+	holidays   *Calendar
+}
+
+// New creates a new window. An empty time zone means UTC, no days means every day and
+// empty start and end mean the whole day. Times are written as "09:00".
+func New(timeZone string, days []string, start, end string) (*Window, error) {
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %w", timeZone, err)
+	}
+
+	w := &Window{location: location, end: 24 * time.Hour}
+	if len(days) == 0 {
+		for d := range w.days {
+			w.days[d] = true
+		}
+	}
+	for _, name := range days {
+		d, ok := dayNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", name)
+		}
+		w.days[d] = true
+	}
+
+	if start != "" {
+		if w.start, err = parseTimeOfDay(start); err != nil {
+			return nil, fmt.Errorf("start: %w", err)
+		}
+	}
+	if end != "" {
+		if w.end, err = parseTimeOfDay(end); err != nil {
+			return nil, fmt.Errorf("end: %w", err)
+		}
+	}
+	if w.start >= w.end {
+		return nil, fmt.Errorf("start %s must be before end %s", start, end)
+	}
+	return w, nil
+}
+
+// SetHolidays excludes the days of the calendar from the window
+func (w *Window) SetHolidays(holidays *Calendar) {
+	w.holidays = holidays
+}
+
+// Location returns the time zone of the window
+func (w *Window) Location() *time.Location {
+	return w.location
+}
+
+// Allowed reports whether a send at t is inside the window
+func (w *Window) Allowed(t time.Time) bool {
+	t = t.In(w.location)
+	if !w.allowedDay(t) {
+		return false
+	}
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	return offset >= w.start && offset < w.end
+}
+
+// Next returns t when it is inside the window, and otherwise the start of the next
+// allowed slot. It returns t unchanged when there is no slot within two years.
+func (w *Window) Next(t time.Time) time.Time {
+	if w.Allowed(t) {
+		return t
+	}
+
+	local := t.In(w.location)
+	day := midnight(local)
+	for i := 0; i <= searchDays; i++ {
+		if w.allowedDay(day) {
+			slot := w.at(day, w.start)
+			if slot.After(local) || slot.Equal(local) {
+				return slot
+			}
+		}
+		day = midnight(day.AddDate(0, 0, 1))
+	}
+	return t
+}
+
+// allowedDay reports whether the day of t is an allowed weekday and not a holiday
+func (w *Window) allowedDay(t time.Time) bool {
+	if !w.days[t.Weekday()] {
+		return false
+	}
+	return w.holidays == nil || !w.holidays.Contains(t)
+}
+
+// at returns the wall clock time offset from midnight of day, so the slot keeps its
+// time of day across daylight saving changes
+func (w *Window) at(day time.Time, offset time.Duration) time.Time {
+	hours, minutes := int(offset/time.Hour), int(offset%time.Hour/time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), hours, minutes, 0, 0, w.location)
+}
+
+// midnight returns the start of the day of t in its location
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// parseTimeOfDay parses "HH:MM" into an offset from midnight. "24:00" is the end of the day.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	hours, minutes, ok := strings.Cut(strings.TrimSpace(s), ":")
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if !ok || errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
@@ -0,0 +1,108 @@
+package window
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWindowNext(t *testing.T) {
+	w, err := New("Europe/Berlin", []string{"mon", "tue", "wed", "thu", "friday"}, "09:00", "17:00")
+	if err != nil {
+		t.Fatalf("Failed to create window: %v", err)
+	}
+	berlin := w.Location()
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected time.Time
+	}{
+		{"inside", time.Date(2025, 1, 8, 10, 30, 0, 0, berlin), time.Date(2025, 1, 8, 10, 30, 0, 0, berlin)},
+		{"at start", time.Date(2025, 1, 8, 9, 0, 0, 0, berlin), time.Date(2025, 1, 8, 9, 0, 0, 0, berlin)},
+		{"before start", time.Date(2025, 1, 8, 6, 15, 0, 0, berlin), time.Date(2025, 1, 8, 9, 0, 0, 0, berlin)},
+		{"at end", time.Date(2025, 1, 8, 17, 0, 0, 0, berlin), time.Date(2025, 1, 9, 9, 0, 0, 0, berlin)},
+		{"friday evening", time.Date(2025, 1, 10, 18, 0, 0, 0, berlin), time.Date(2025, 1, 13, 9, 0, 0, 0, berlin)},
+		{"saturday", time.Date(2025, 1, 11, 12, 0, 0, 0, berlin), time.Date(2025, 1, 13, 9, 0, 0, 0, berlin)},
+		// 07:30 UTC is 08:30 in Berlin in winter
+		{"other time zone", time.Date(2025, 1, 8, 7, 30, 0, 0, time.UTC), time.Date(2025, 1, 8, 9, 0, 0, 0, berlin)},
+		// Clocks go forward on Sunday 30 March 2025, the slot stays at 09:00
+		{"daylight saving", time.Date(2025, 3, 29, 12, 0, 0, 0, berlin), time.Date(2025, 3, 31, 9, 0, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.Next(tt.at); !got.Equal(tt.expected) {
+				t.Errorf("Next(%v): got %v want %v", tt.at, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWindowHolidays(t *testing.T) {
+	w, err := New("UTC", []string{"Mon", "Tue", "Wed", "Thu", "Fri"}, "09:00", "17:00")
+	if err != nil {
+		t.Fatalf("Failed to create window: %v", err)
+	}
+	holidays, err := ParseCalendar(strings.NewReader(testCalendar))
+	if err != nil {
+		t.Fatalf("Failed to parse calendar: %v", err)
+	}
+	w.SetHolidays(holidays)
+
+	// New Year's Day 2026 is a Thursday and Friday 2 January is a bridge day
+	at := time.Date(2025, 12, 31, 17, 30, 0, 0, time.UTC)
+	expected := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	if got := w.Next(at); !got.Equal(expected) {
+		t.Errorf("Next(%v): got %v want %v", at, got, expected)
+	}
+
+	if w.Allowed(time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Error("Expected the bridge day to be excluded")
+	}
+}
+
+func TestWindowDefaults(t *testing.T) {
+	w, err := New("", nil, "", "")
+	if err != nil {
+		t.Fatalf("Failed to create window: %v", err)
+	}
+	at := time.Date(2025, 1, 11, 3, 0, 0, 0, time.UTC)
+	if got := w.Next(at); !got.Equal(at) {
+		t.Errorf("Expected an unrestricted window to keep %v, got %v", at, got)
+	}
+
+	// The end of the day can be written as 24:00
+	w, err = New("UTC", []string{"sat"}, "20:00", "24:00")
+	if err != nil {
+		t.Fatalf("Failed to create window: %v", err)
+	}
+	if !w.Allowed(time.Date(2025, 1, 11, 23, 59, 0, 0, time.UTC)) {
+		t.Error("Expected 23:59 to be inside a window ending at 24:00")
+	}
+}
+
+func TestNewWindowValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeZone string
+		days     []string
+		start    string
+		end      string
+	}{
+		{"unknown time zone", "Mars/Olympus", nil, "", ""},
+		{"unknown weekday", "UTC", []string{"funday"}, "", ""},
+		{"invalid start", "UTC", nil, "9am", ""},
+		{"invalid end", "UTC", nil, "", "25:00"},
+		{"start after end", "UTC", nil, "17:00", "09:00"},
+		{"empty window", "UTC", nil, "09:00", "09:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.timeZone, tt.days, tt.start, tt.end); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}